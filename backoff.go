@@ -6,6 +6,56 @@ import (
 	"time"
 )
 
+// Backoff is a resettable sequence of delays.
+type Backoff interface {
+	// Next returns the next delay of the sequence.
+	Next() time.Duration
+
+	// Reset starts the sequence from the beginning.
+	Reset()
+}
+
+// NewBackoff makes a Backoff from the given algorithm constructor. The
+// constructor is called again each time the Backoff is reset.
+func NewBackoff(algorithm func() func() time.Duration) Backoff {
+	return &algorithmBackoff{algorithm: algorithm, next: algorithm()}
+}
+
+type algorithmBackoff struct {
+	algorithm func() func() time.Duration
+	next      func() time.Duration
+}
+
+func (b *algorithmBackoff) Next() time.Duration {
+	return b.next()
+}
+
+func (b *algorithmBackoff) Reset() {
+	b.next = b.algorithm()
+}
+
+// BackoffBuilder is implemented by all backoff builders.
+type BackoffBuilder interface {
+	// Set creates a Delay' option.
+	Set() func(*DelayOptions)
+}
+
+// BuildBackoff creates a new Backoff using the given builder. It allows
+// to use backoff builders outside of WithDelay.
+func BuildBackoff(b BackoffBuilder) Backoff {
+	do := &DelayOptions{}
+	b.Set()(do)
+	if do.Resettable != nil {
+		return do.Resettable
+	}
+
+	// The builder does not know about Resettable.
+	next := do.Backoff
+	return NewBackoff(func() func() time.Duration {
+		return next
+	})
+}
+
 // FixedBackoffAlgorithm implements backoff with a fixed delay.
 func FixedBackoffAlgorithm(delay time.Duration) func() time.Duration {
 	return func() time.Duration {
@@ -22,10 +72,19 @@ type FixedBackoffBuilder struct {
 // Set creates a Delay' option.
 func (s *FixedBackoffBuilder) Set() func(*DelayOptions) {
 	return func(do *DelayOptions) {
-		do.Backoff = FixedBackoffAlgorithm(s.Delay)
+		delay := s.Delay
+		do.setBackoff(NewBackoff(func() func() time.Duration {
+			return FixedBackoffAlgorithm(delay)
+		}))
 	}
 }
 
+// String returns the builder in the compact syntax accepted by
+// ParseBackoff.
+func (s *FixedBackoffBuilder) String() string {
+	return formatBackoff("fixed", s.Delay.String())
+}
+
 // FixedBackoff create a builder for Delay's option.
 func FixedBackoff(delay time.Duration) *FixedBackoffBuilder {
 	return &FixedBackoffBuilder{Delay: delay}
@@ -98,10 +157,19 @@ func (s *FullJitterBackoffBuilder) WithBaseDelay(d time.Duration) *FullJitterBac
 // Set creates a Delay' option.
 func (s *FullJitterBackoffBuilder) Set() func(*DelayOptions) {
 	return func(do *DelayOptions) {
-		do.Backoff = FullJitterBackoffAlgorithm(s.BaseDelay, s.MaxDelay)
+		baseDelay, maxDelay := s.BaseDelay, s.MaxDelay
+		do.setBackoff(NewBackoff(func() func() time.Duration {
+			return FullJitterBackoffAlgorithm(baseDelay, maxDelay)
+		}))
 	}
 }
 
+// String returns the builder in the compact syntax accepted by
+// ParseBackoff.
+func (s *FullJitterBackoffBuilder) String() string {
+	return formatBackoff("fulljitter", s.BaseDelay.String(), maxDelayParam(s.MaxDelay)...)
+}
+
 // FullJitterBackoff create a builder for Delay's option.
 func FullJitterBackoff(baseDelay time.Duration) *FullJitterBackoffBuilder {
 	return (&FullJitterBackoffBuilder{}).
@@ -218,10 +286,28 @@ func (s *ExponentialBackoffBuilder) WithJitter(j float64) *ExponentialBackoffBui
 // Set creates a Delay' option.
 func (s *ExponentialBackoffBuilder) Set() func(*DelayOptions) {
 	return func(do *DelayOptions) {
-		do.Backoff = ExponentialBackoffAlgorithm(s.InitialDelay, s.MaxDelay, s.Multiplier, s.Jitter)
+		initialDelay, maxDelay, multiplier, jitter := s.InitialDelay, s.MaxDelay, s.Multiplier, s.Jitter
+		do.setBackoff(NewBackoff(func() func() time.Duration {
+			return ExponentialBackoffAlgorithm(initialDelay, maxDelay, multiplier, jitter)
+		}))
 	}
 }
 
+// String returns the builder in the compact syntax accepted by
+// ParseBackoff.
+func (s *ExponentialBackoffBuilder) String() string {
+	params := []string{"initial=" + s.InitialDelay.String()}
+	if s.Multiplier != 2 {
+		params = append(params, "mult="+formatFloat(s.Multiplier))
+	}
+	params = append(params, maxDelayParam(s.MaxDelay)...)
+	if s.Jitter != 0 {
+		params = append(params, "jitter="+formatFloat(s.Jitter))
+	}
+
+	return formatBackoff("exp", params[0], params[1:]...)
+}
+
 // ExponentialBackoff create a builder for Delay's option.
 func ExponentialBackoff(initialDelay time.Duration) *ExponentialBackoffBuilder {
 	return (&ExponentialBackoffBuilder{}).
@@ -230,3 +316,188 @@ func ExponentialBackoff(initialDelay time.Duration) *ExponentialBackoffBuilder {
 		WithMultiplier(2).
 		WithJitter(0)
 }
+
+// DecorrelatedJitterBackoffAlgorithm implements caped decorrelated
+// jitter backoff. Each delay is a random value between the base delay
+// and the tripled previous delay.
+//
+// Details:
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+//
+// Example (BaseDelay=1, maxDelay=30):
+// Call			Delay
+// -------      ----------------
+// 1            random [1...3]
+// 2            random [1...3*previous]
+// ...
+// N            min(30, random [1...3*previous])
+//
+func DecorrelatedJitterBackoffAlgorithm(baseDelay time.Duration, maxDelay time.Duration) func() time.Duration {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	delay := baseDelay
+
+	return func() time.Duration {
+		upper := time.Duration(1<<63 - 1)
+		if delay <= upper/3 {
+			upper = delay * 3
+		}
+
+		delay = baseDelay
+		if upper > baseDelay {
+			delay += time.Duration(rnd.Int63n(int64(upper - baseDelay)))
+		}
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+
+		return delay
+	}
+}
+
+// DecorrelatedJitterBackoffBuilder is an option builder.
+type DecorrelatedJitterBackoffBuilder struct {
+	// MaxDelay specifies maximum value of a delay calculated by the
+	// algorithm.
+	//
+	// Default value is maximum time.Duration value.
+	MaxDelay time.Duration
+
+	// BaseDelay specifies the minimum value of a delay calculated by
+	// the algorithm.
+	BaseDelay time.Duration
+}
+
+// WithMaxDelay allows to set MaxDelay.
+//
+// MaxDelay specifies the maximum value of a delay calculated by the
+// algorithm.
+//
+// Default value is maximum time.Duration value.
+func (s *DecorrelatedJitterBackoffBuilder) WithMaxDelay(d time.Duration) *DecorrelatedJitterBackoffBuilder {
+	s.MaxDelay = d
+	return s
+}
+
+// WithBaseDelay allows to set BaseDelay.
+//
+// BaseDelay specifies the minimum value of a delay calculated by the
+// algorithm.
+func (s *DecorrelatedJitterBackoffBuilder) WithBaseDelay(d time.Duration) *DecorrelatedJitterBackoffBuilder {
+	s.BaseDelay = d
+	return s
+}
+
+// Set creates a Delay' option.
+func (s *DecorrelatedJitterBackoffBuilder) Set() func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		baseDelay, maxDelay := s.BaseDelay, s.MaxDelay
+		do.setBackoff(NewBackoff(func() func() time.Duration {
+			return DecorrelatedJitterBackoffAlgorithm(baseDelay, maxDelay)
+		}))
+	}
+}
+
+// String returns the builder in the compact syntax accepted by
+// ParseBackoff.
+func (s *DecorrelatedJitterBackoffBuilder) String() string {
+	return formatBackoff("decorrelated", s.BaseDelay.String(), maxDelayParam(s.MaxDelay)...)
+}
+
+// DecorrelatedJitterBackoff create a builder for Delay's option.
+func DecorrelatedJitterBackoff(baseDelay time.Duration) *DecorrelatedJitterBackoffBuilder {
+	return (&DecorrelatedJitterBackoffBuilder{}).
+		WithBaseDelay(baseDelay).
+		WithMaxDelay(1<<63 - 1)
+}
+
+// EqualJitterBackoffAlgorithm implements caped exponential backoff
+// with equal jitter: a half of the exponential delay is fixed and
+// another half is random. Unlike the full jitter it never returns
+// delays less than a half of the exponential delay.
+//
+// Details:
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+//
+// Example (BaseDelay=2, maxDelay=30):
+// Call			Delay
+// -------      ----------------
+// 1             1 + random [0...1]
+// 2             2 + random [0...2]
+// 3             4 + random [0...4]
+// 4             8 + random [0...8]
+// 5            15 + random [0...15]
+// 6            15 + random [0...15]
+//
+func EqualJitterBackoffAlgorithm(baseDelay time.Duration, maxDelay time.Duration) func() time.Duration {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	delay := baseDelay
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return func() time.Duration {
+		defer func() {
+			if delay > maxDelay/2 {
+				delay = maxDelay
+			} else {
+				delay = delay << 1
+			}
+		}()
+		half := delay / 2
+		return delay - half + time.Duration(rnd.Int63n(int64(half)+1))
+	}
+}
+
+// EqualJitterBackoffBuilder is an option builder.
+type EqualJitterBackoffBuilder struct {
+	// MaxDelay specifies maximum value of a delay calculated by the
+	// algorithm.
+	//
+	// Default value is maximum time.Duration value.
+	MaxDelay time.Duration
+
+	// BaseDelay specifies base of an exponent.
+	BaseDelay time.Duration
+}
+
+// WithMaxDelay allows to set MaxDelay.
+//
+// MaxDelay specifies the maximum value of a delay calculated by the
+// algorithm.
+//
+// Default value is maximum time.Duration value.
+func (s *EqualJitterBackoffBuilder) WithMaxDelay(d time.Duration) *EqualJitterBackoffBuilder {
+	s.MaxDelay = d
+	return s
+}
+
+// WithBaseDelay allows to set BaseDelay.
+//
+// BaseDelay specifies base of an exponent.
+func (s *EqualJitterBackoffBuilder) WithBaseDelay(d time.Duration) *EqualJitterBackoffBuilder {
+	s.BaseDelay = d
+	return s
+}
+
+// Set creates a Delay' option.
+func (s *EqualJitterBackoffBuilder) Set() func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		baseDelay, maxDelay := s.BaseDelay, s.MaxDelay
+		do.setBackoff(NewBackoff(func() func() time.Duration {
+			return EqualJitterBackoffAlgorithm(baseDelay, maxDelay)
+		}))
+	}
+}
+
+// String returns the builder in the compact syntax accepted by
+// ParseBackoff.
+func (s *EqualJitterBackoffBuilder) String() string {
+	return formatBackoff("equaljitter", s.BaseDelay.String(), maxDelayParam(s.MaxDelay)...)
+}
+
+// EqualJitterBackoff create a builder for Delay's option.
+func EqualJitterBackoff(baseDelay time.Duration) *EqualJitterBackoffBuilder {
+	return (&EqualJitterBackoffBuilder{}).
+		WithBaseDelay(baseDelay).
+		WithMaxDelay(1<<63 - 1)
+}
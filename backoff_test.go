@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConstantBackoff(t *testing.T) {
@@ -85,3 +86,146 @@ func TestExponentialBackoff(t *testing.T) {
 		InRange(t, do.Backoff(), time.Duration(c-fi), time.Duration(c+fi))
 	}
 }
+
+func TestBackoffReset(t *testing.T) {
+	do := &DelayOptions{}
+	ExponentialBackoff(1).Set()(do)
+
+	require.EqualValues(t, 1, do.Backoff())
+	require.EqualValues(t, 2, do.Backoff())
+	require.EqualValues(t, 4, do.Backoff())
+
+	do.Resettable.Reset()
+	require.EqualValues(t, 1, do.Backoff())
+	require.EqualValues(t, 2, do.Backoff())
+}
+
+func TestBuilderIsReadOnApply(t *testing.T) {
+	b := FixedBackoff(1)
+	set := b.Set()
+	b.Delay = 2
+
+	do := &DelayOptions{}
+	set(do)
+	require.EqualValues(t, 2, do.Backoff())
+}
+
+func TestDecorrelatedJitterBackoffDefaults(t *testing.T) {
+	do := &DelayOptions{}
+	DecorrelatedJitterBackoff(time.Second).Set()(do)
+
+	prev := time.Second
+	for i := 0; i < 100; i++ {
+		delay := do.Backoff()
+		max := time.Duration(1<<63 - 1)
+		if prev <= max/3 {
+			max = 3 * prev
+		}
+		InRange(t, delay, time.Second, max)
+		prev = delay
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	do := &DelayOptions{}
+	DecorrelatedJitterBackoff(1).WithMaxDelay(30).Set()(do)
+
+	prev := time.Duration(1)
+	for i := 0; i < 100; i++ {
+		delay := do.Backoff()
+		max := 3 * prev
+		if max > 30 {
+			max = 30
+		}
+		InRange(t, delay, 1, max)
+		prev = delay
+	}
+}
+
+func TestDecorrelatedJitterBackoffNoOverflow(t *testing.T) {
+	fn := DecorrelatedJitterBackoffAlgorithm(1<<62, 1<<63-1)
+
+	for i := 0; i < 10; i++ {
+		InRange(t, fn(), 1<<62, 1<<63-1)
+	}
+}
+
+func TestDecorrelatedJitterBackoffMaxBelowBase(t *testing.T) {
+	fn := DecorrelatedJitterBackoffAlgorithm(10, 5)
+	assert.EqualValues(t, 5, fn())
+	assert.EqualValues(t, 5, fn())
+}
+
+func TestEqualJitterBackoffDefaults(t *testing.T) {
+	do := &DelayOptions{}
+	EqualJitterBackoff(time.Second).Set()(do)
+
+	for i := 0; i < 20; i++ {
+		c := time.Duration(math.Pow(2, float64(i))) * time.Second
+		InRange(t, do.Backoff(), c/2, c)
+	}
+}
+
+func TestEqualJitterBackoff(t *testing.T) {
+	do := &DelayOptions{}
+	EqualJitterBackoff(2).WithMaxDelay(30).Set()(do)
+
+	for i := 0; i < 50; i++ {
+		c := int64(2 * math.Pow(2, float64(i)))
+		if c > 30 || c <= 0 {
+			c = 30
+		}
+		InRange(t, do.Backoff(), time.Duration(c/2), time.Duration(c))
+	}
+}
+
+func TestEqualJitterBackoffNoOverflow(t *testing.T) {
+	fn := EqualJitterBackoffAlgorithm(time.Second, 1<<63-1)
+
+	for i := 0; i < 100; i++ {
+		InRange(t, fn(), time.Second/2, 1<<63-1)
+	}
+}
+
+func TestBuildBackoff(t *testing.T) {
+	for _, b := range []BackoffBuilder{
+		FixedBackoff(1),
+		FullJitterBackoff(1),
+		ExponentialBackoff(1),
+		DecorrelatedJitterBackoff(1),
+		EqualJitterBackoff(1),
+	} {
+		require.NotNil(t, BuildBackoff(b))
+	}
+
+	b := BuildBackoff(ExponentialBackoff(1))
+	require.EqualValues(t, 1, b.Next())
+	require.EqualValues(t, 2, b.Next())
+}
+
+// funcBackoffBuilder sets only DelayOptions.Backoff like builders
+// written before Resettable.
+type funcBackoffBuilder struct{}
+
+func (funcBackoffBuilder) Set() func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.Backoff = func() time.Duration { return 7 }
+	}
+}
+
+func TestBuildBackoff_FuncBuilder(t *testing.T) {
+	b := BuildBackoff(funcBackoffBuilder{})
+	require.EqualValues(t, 7, b.Next())
+	b.Reset()
+	require.EqualValues(t, 7, b.Next())
+}
+
+func TestDefaultOptions_FuncBuilder(t *testing.T) {
+	do := applyOptions(applyOptions(&DelayOptions{}, defaultOptions()), []func(*DelayOptions){
+		funcBackoffBuilder{}.Set(),
+		SetBackoffResetOnSuccess(),
+	})
+	require.Nil(t, do.Resettable, "there should be no stale sequence to reset")
+	require.EqualValues(t, 7, do.Backoff())
+	require.Equal(t, time.Second, applyOptions(&DelayOptions{}, defaultOptions()).Backoff())
+}
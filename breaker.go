@@ -0,0 +1,282 @@
+package repeat
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the cause of StopError returned by CircuitBreaker
+// when it does not allow to call an operation.
+var ErrCircuitOpen = errors.New("repeat: circuit breaker is open")
+
+// CircuitState is a state of CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed allows all calls and counts failures.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects all calls until the cool-down period ends.
+	CircuitOpen
+
+	// CircuitHalfOpen allows a limited number of probe calls. A success
+	// closes the circuit, a failure opens it again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// SetCircuitConsecutiveFailures specifies the number of consecutive
+// failures that opens the circuit. Zero value disables the check.
+//
+// Default value is 5.
+func SetCircuitConsecutiveFailures(n int) func(*CircuitBreakerOptions) {
+	return func(o *CircuitBreakerOptions) {
+		o.ConsecutiveFailures = n
+	}
+}
+
+// SetCircuitFailureRatio specifies the ratio of failed calls [0..1]
+// that opens the circuit. The ratio is checked only after at least
+// minCalls calls. Zero ratio disables the check.
+//
+// Default value is 0.
+func SetCircuitFailureRatio(ratio float64, minCalls int) func(*CircuitBreakerOptions) {
+	return func(o *CircuitBreakerOptions) {
+		o.FailureRatio = ratio
+		o.MinCalls = minCalls
+	}
+}
+
+// SetCircuitInterval specifies the period the failure counters of the
+// closed circuit are reset with. Zero value means the counters are
+// reset only when the state changes.
+//
+// Default value is 0.
+func SetCircuitInterval(d time.Duration) func(*CircuitBreakerOptions) {
+	return func(o *CircuitBreakerOptions) {
+		o.Interval = d
+	}
+}
+
+// SetCircuitCoolDown specifies how long the circuit stays open before
+// it becomes half-open.
+//
+// Default value is 30 seconds.
+func SetCircuitCoolDown(d time.Duration) func(*CircuitBreakerOptions) {
+	return func(o *CircuitBreakerOptions) {
+		o.CoolDown = d
+	}
+}
+
+// SetCircuitHalfOpenCalls specifies the maximum number of concurrent
+// probe calls in the half-open state.
+//
+// Default value is 1.
+func SetCircuitHalfOpenCalls(n int) func(*CircuitBreakerOptions) {
+	return func(o *CircuitBreakerOptions) {
+		o.HalfOpenCalls = n
+	}
+}
+
+// SetCircuitClock allows to set a clock instead of the system one.
+func SetCircuitClock(c Clock) func(*CircuitBreakerOptions) {
+	return func(o *CircuitBreakerOptions) {
+		o.Clock = c
+	}
+}
+
+// CircuitBreakerOptions holds parameters for a circuit breaker.
+type CircuitBreakerOptions struct {
+	ConsecutiveFailures int
+	FailureRatio        float64
+	MinCalls            int
+	Interval            time.Duration
+	CoolDown            time.Duration
+	HalfOpenCalls       int
+	Clock               Clock
+}
+
+// CircuitBreaker stops calling an operation that keeps failing to give
+// it time to recover.
+//
+// Outcomes of the operation are classified the same way the repetition
+// loop does it: nil and StopError without a cause are successes,
+// TemporaryError and any other error are failures. StopError with a
+// cause is a decision of the operation itself, it is not counted.
+//
+// CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	mu sync.Mutex
+	o  CircuitBreakerOptions
+
+	state      CircuitState
+	generation uint64
+	openedAt   time.Time
+	resetAt    time.Time
+
+	calls       int
+	failures    int
+	consecutive int
+	probes      int
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(options ...func(*CircuitBreakerOptions)) *CircuitBreaker {
+	o := CircuitBreakerOptions{
+		ConsecutiveFailures: 5,
+		CoolDown:            30 * time.Second,
+		HalfOpenCalls:       1,
+		Clock:               SystemClock,
+	}
+	for _, option := range options {
+		option(&o)
+	}
+
+	cb := &CircuitBreaker{o: o}
+	cb.resetAt = cb.o.Clock.Now()
+
+	return cb
+}
+
+// State returns the current state of the circuit.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.update(cb.o.Clock.Now())
+
+	return cb.state
+}
+
+// Wrap is an OpWrapper that calls op only if the circuit allows it and
+// learns from the op's outcome. If the circuit does not allow the call,
+// it returns StopError with ErrCircuitOpen cause.
+func (cb *CircuitBreaker) Wrap(op Operation) Operation {
+	return func(e error) error {
+		generation, ok := cb.allow()
+		if !ok {
+			return HintStop(ErrCircuitOpen)
+		}
+
+		err := op(e)
+		cb.report(generation, err)
+
+		return err
+	}
+}
+
+// allow checks if a call is allowed and returns the generation of the
+// state the call belongs to.
+func (cb *CircuitBreaker) allow() (uint64, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.update(cb.o.Clock.Now())
+
+	switch cb.state {
+	case CircuitClosed:
+		return cb.generation, true
+	case CircuitHalfOpen:
+		if cb.probes < cb.o.HalfOpenCalls {
+			cb.probes++
+			return cb.generation, true
+		}
+	}
+
+	return cb.generation, false
+}
+
+// report learns from the outcome of a call. Outcomes of calls allowed
+// in other states are ignored.
+func (cb *CircuitBreaker) report(generation uint64, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.o.Clock.Now()
+	cb.update(now)
+	if generation != cb.generation {
+		return
+	}
+
+	switch cb.state {
+	case CircuitClosed:
+		switch {
+		case IsStop(err) && Cause(err) != nil:
+		case err == nil || IsStop(err):
+			cb.calls++
+			cb.consecutive = 0
+		default:
+			cb.calls++
+			cb.failures++
+			cb.consecutive++
+			if cb.tripped() {
+				cb.setState(CircuitOpen, now)
+			}
+		}
+
+	case CircuitHalfOpen:
+		switch {
+		case IsStop(err) && Cause(err) != nil:
+			cb.probes--
+		case err == nil || IsStop(err):
+			cb.setState(CircuitClosed, now)
+		default:
+			cb.setState(CircuitOpen, now)
+		}
+	}
+}
+
+// tripped checks if failure thresholds are exceeded.
+func (cb *CircuitBreaker) tripped() bool {
+	if cb.o.ConsecutiveFailures > 0 && cb.consecutive >= cb.o.ConsecutiveFailures {
+		return true
+	}
+
+	return cb.o.FailureRatio > 0 && cb.calls >= cb.o.MinCalls &&
+		float64(cb.failures) >= cb.o.FailureRatio*float64(cb.calls)
+}
+
+// update moves the circuit to the half-open state after the cool-down
+// period and resets counters of the closed circuit each interval.
+func (cb *CircuitBreaker) update(now time.Time) {
+	switch cb.state {
+	case CircuitOpen:
+		if !now.Before(cb.openedAt.Add(cb.o.CoolDown)) {
+			cb.setState(CircuitHalfOpen, now)
+		}
+	case CircuitClosed:
+		if cb.o.Interval > 0 && !now.Before(cb.resetAt.Add(cb.o.Interval)) {
+			cb.resetCounters(now)
+		}
+	}
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState, now time.Time) {
+	cb.state = state
+	cb.generation++
+	cb.probes = 0
+	cb.resetCounters(now)
+	if state == CircuitOpen {
+		cb.openedAt = now
+	}
+}
+
+func (cb *CircuitBreaker) resetCounters(now time.Time) {
+	cb.calls = 0
+	cb.failures = 0
+	cb.consecutive = 0
+	cb.resetAt = now
+}
@@ -0,0 +1,200 @@
+package repeat_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+func fail(error) error {
+	return repeat.HintTemporary(errPeanut)
+}
+
+func TestCircuitState_String(t *testing.T) {
+	require.Equal(t, "closed", repeat.CircuitClosed.String())
+	require.Equal(t, "open", repeat.CircuitOpen.String())
+	require.Equal(t, "half-open", repeat.CircuitHalfOpen.String())
+	require.Equal(t, "unknown", repeat.CircuitState(42).String())
+}
+
+func TestCircuitBreaker_ConsecutiveFailures(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	cb := repeat.NewCircuitBreaker(
+		repeat.SetCircuitConsecutiveFailures(3),
+		repeat.SetCircuitCoolDown(time.Minute),
+		repeat.SetCircuitClock(c),
+	)
+
+	// A success resets consecutive failures.
+	for _, op := range []repeat.Operation{fail, fail, repeat.Nope, fail, fail} {
+		cb.Wrap(op)(nil)
+		require.Equal(t, repeat.CircuitClosed, cb.State())
+	}
+
+	require.True(t, repeat.IsTemporary(cb.Wrap(fail)(nil)))
+	require.Equal(t, repeat.CircuitOpen, cb.State())
+
+	err := cb.Wrap(func(error) error {
+		require.Fail(t, "should be never called")
+		return nil
+	})(nil)
+	require.True(t, repeat.IsStop(err))
+	require.True(t, errors.Is(err, repeat.ErrCircuitOpen))
+
+	c.Advance(time.Minute)
+	require.Equal(t, repeat.CircuitHalfOpen, cb.State())
+	require.NoError(t, cb.Wrap(repeat.Nope)(nil))
+	require.Equal(t, repeat.CircuitClosed, cb.State())
+}
+
+func TestCircuitBreaker_HalfOpenFailure(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	cb := repeat.NewCircuitBreaker(
+		repeat.SetCircuitConsecutiveFailures(1),
+		repeat.SetCircuitCoolDown(time.Minute),
+		repeat.SetCircuitClock(c),
+	)
+
+	cb.Wrap(fail)(nil)
+	c.Advance(time.Minute)
+	require.Equal(t, errPeanut, repeat.Cause(cb.Wrap(func(error) error { return errPeanut })(nil)))
+	require.Equal(t, repeat.CircuitOpen, cb.State())
+
+	c.Advance(time.Minute - time.Nanosecond)
+	require.Equal(t, repeat.CircuitOpen, cb.State())
+	c.Advance(time.Nanosecond)
+	require.Equal(t, repeat.CircuitHalfOpen, cb.State())
+}
+
+func TestCircuitBreaker_HalfOpenCalls(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	cb := repeat.NewCircuitBreaker(
+		repeat.SetCircuitConsecutiveFailures(1),
+		repeat.SetCircuitHalfOpenCalls(2),
+		repeat.SetCircuitCoolDown(time.Minute),
+		repeat.SetCircuitClock(c),
+	)
+	cb.Wrap(fail)(nil)
+	c.Advance(time.Minute)
+
+	// Two probes are allowed, the third one is rejected while they are
+	// in progress.
+	var wg sync.WaitGroup
+	started := make(chan struct{})
+	release := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cb.Wrap(func(e error) error {
+				started <- struct{}{}
+				<-release
+				return repeat.HintStop(errPeanut)
+			})(nil)
+		}()
+	}
+	<-started
+	<-started
+	require.True(t, errors.Is(cb.Wrap(repeat.Nope)(nil), repeat.ErrCircuitOpen))
+
+	// Stop errors with a cause are not counted and free probe slots.
+	close(release)
+	wg.Wait()
+	require.Equal(t, repeat.CircuitHalfOpen, cb.State())
+	require.NoError(t, cb.Wrap(repeat.Nope)(nil))
+	require.Equal(t, repeat.CircuitClosed, cb.State())
+}
+
+func TestCircuitBreaker_FailureRatio(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	cb := repeat.NewCircuitBreaker(
+		repeat.SetCircuitConsecutiveFailures(0),
+		repeat.SetCircuitFailureRatio(0.5, 4),
+		repeat.SetCircuitClock(c),
+	)
+
+	for _, op := range []repeat.Operation{fail, repeat.Nope, repeat.HintStop} {
+		cb.Wrap(op)(nil)
+		require.Equal(t, repeat.CircuitClosed, cb.State())
+	}
+
+	// StopError with a cause is not counted.
+	cb.Wrap(repeat.HintStop)(errPeanut)
+	require.Equal(t, repeat.CircuitClosed, cb.State())
+
+	cb.Wrap(fail)(nil)
+	require.Equal(t, repeat.CircuitOpen, cb.State())
+}
+
+func TestCircuitBreaker_Interval(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	cb := repeat.NewCircuitBreaker(
+		repeat.SetCircuitConsecutiveFailures(2),
+		repeat.SetCircuitInterval(time.Minute),
+		repeat.SetCircuitClock(c),
+	)
+
+	cb.Wrap(fail)(nil)
+	c.Advance(time.Minute)
+	cb.Wrap(fail)(nil)
+	require.Equal(t, repeat.CircuitClosed, cb.State())
+	cb.Wrap(fail)(nil)
+	require.Equal(t, repeat.CircuitOpen, cb.State())
+}
+
+func TestCircuitBreaker_StaleOutcomeIgnored(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	cb := repeat.NewCircuitBreaker(
+		repeat.SetCircuitConsecutiveFailures(1),
+		repeat.SetCircuitCoolDown(time.Minute),
+		repeat.SetCircuitClock(c),
+	)
+
+	// The slow call succeeds after the circuit has been opened by
+	// another one. It should not close the circuit.
+	cb.Wrap(func(e error) error {
+		cb.Wrap(fail)(nil)
+		return nil
+	})(nil)
+	require.Equal(t, repeat.CircuitOpen, cb.State())
+}
+
+func TestCircuitBreaker_Repeat(t *testing.T) {
+	cb := repeat.NewCircuitBreaker(repeat.SetCircuitConsecutiveFailures(3))
+
+	cn := 0
+	err := repeat.Repeat(
+		cb.Wrap(func(e error) error {
+			cn++
+			return repeat.HintTemporary(errPeanut)
+		}),
+		repeat.StopOnSuccess(),
+		repeat.LimitMaxTries(10),
+	)
+	require.Equal(t, repeat.ErrCircuitOpen, err)
+	require.Equal(t, 3, cn)
+}
+
+func TestCircuitBreaker_Concurrent(t *testing.T) {
+	cb := repeat.NewCircuitBreaker(repeat.SetCircuitConsecutiveFailures(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cb.Wrap(fail)(nil)
+				cb.State()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, repeat.CircuitClosed, cb.State())
+}
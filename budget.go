@@ -0,0 +1,151 @@
+package repeat
+
+import (
+	"sync"
+	"time"
+)
+
+// SetBudgetRatio specifies the fraction of a token each successful
+// call deposits to the budget.
+//
+// Default value is 0.1 that allows one retry per ten successes.
+func SetBudgetRatio(r float64) func(*RetryBudgetOptions) {
+	return func(o *RetryBudgetOptions) {
+		o.Ratio = r
+	}
+}
+
+// SetBudgetMinPerSecond specifies the number of tokens deposited to the
+// budget each second regardless of successes. It allows some retries
+// when there is no traffic.
+//
+// Default value is 1.
+func SetBudgetMinPerSecond(n float64) func(*RetryBudgetOptions) {
+	return func(o *RetryBudgetOptions) {
+		o.MinPerSecond = n
+	}
+}
+
+// SetBudgetMaxTokens specifies the capacity of the budget. The budget
+// is full when created.
+//
+// Default value is 10.
+func SetBudgetMaxTokens(n float64) func(*RetryBudgetOptions) {
+	return func(o *RetryBudgetOptions) {
+		o.MaxTokens = n
+	}
+}
+
+// SetBudgetClock allows to set a clock instead of the system one.
+func SetBudgetClock(c Clock) func(*RetryBudgetOptions) {
+	return func(o *RetryBudgetOptions) {
+		o.Clock = c
+	}
+}
+
+// RetryBudgetOptions holds parameters for a retry budget.
+type RetryBudgetOptions struct {
+	Ratio        float64
+	MinPerSecond float64
+	MaxTokens    float64
+	Clock        Clock
+}
+
+// RetryBudget is a token bucket that limits the number of retries
+// relative to the number of successful calls. It prevents unbounded
+// retries from many goroutines from amplifying an outage.
+//
+// RetryBudget is safe for concurrent use and is intended to be shared
+// by many repetitions.
+type RetryBudget struct {
+	mu     sync.Mutex
+	o      RetryBudgetOptions
+	tokens float64
+	last   time.Time
+}
+
+// NewRetryBudget creates a full RetryBudget.
+func NewRetryBudget(options ...func(*RetryBudgetOptions)) *RetryBudget {
+	o := RetryBudgetOptions{
+		Ratio:        0.1,
+		MinPerSecond: 1,
+		MaxTokens:    10,
+		Clock:        SystemClock,
+	}
+	for _, option := range options {
+		option(&o)
+	}
+
+	return &RetryBudget{o: o, tokens: o.MaxTokens, last: o.Clock.Now()}
+}
+
+// Deposit adds a fraction of a token for a successful call.
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	b.add(b.o.Ratio)
+}
+
+// Withdraw takes a token for a retry. It returns false if the budget
+// is exhausted.
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+
+	return true
+}
+
+// Tokens returns the number of available tokens.
+func (b *RetryBudget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+
+	return b.tokens
+}
+
+// refill deposits tokens for the time elapsed since the last refill.
+func (b *RetryBudget) refill() {
+	now := b.o.Clock.Now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.add(elapsed.Seconds() * b.o.MinPerSecond)
+	}
+	b.last = now
+}
+
+func (b *RetryBudget) add(n float64) {
+	b.tokens += n
+	if b.tokens > b.o.MaxTokens {
+		b.tokens = b.o.MaxTokens
+	}
+}
+
+// LimitRetryBudget deposits to the budget in case of error is nil and
+// withdraws from it otherwise. It stops the repetition with the last
+// cause when the budget is exhausted.
+//
+// Note! Place it before StopOnSuccess, otherwise successes are not
+// deposited.
+func LimitRetryBudget(b *RetryBudget) Operation {
+	return func(e error) error {
+		if e == nil {
+			b.Deposit()
+			return e
+		}
+
+		if b.Withdraw() {
+			return e
+		}
+
+		return HintStop(e)
+	}
+}
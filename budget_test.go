@@ -0,0 +1,101 @@
+package repeat_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+func TestRetryBudget(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	b := repeat.NewRetryBudget(
+		repeat.SetBudgetMaxTokens(2),
+		repeat.SetBudgetRatio(0.5),
+		repeat.SetBudgetMinPerSecond(0.1),
+		repeat.SetBudgetClock(c),
+	)
+	require.Equal(t, 2.0, b.Tokens())
+
+	require.True(t, b.Withdraw())
+	require.True(t, b.Withdraw())
+	require.False(t, b.Withdraw())
+
+	// Two successes give one retry.
+	b.Deposit()
+	require.False(t, b.Withdraw())
+	b.Deposit()
+	require.True(t, b.Withdraw())
+
+	// Ten seconds give one retry.
+	c.Advance(10 * time.Second)
+	require.True(t, b.Withdraw())
+	require.False(t, b.Withdraw())
+
+	// The budget is capped.
+	c.Advance(time.Hour)
+	b.Deposit()
+	require.Equal(t, 2.0, b.Tokens())
+}
+
+func TestLimitRetryBudget(t *testing.T) {
+	b := repeat.NewRetryBudget(
+		repeat.SetBudgetMaxTokens(3),
+		repeat.SetBudgetMinPerSecond(0),
+		repeat.SetBudgetClock(repeattest.NewFakeClock(epoch)),
+	)
+
+	cn := 0
+	err := repeat.Repeat(
+		repeat.FnWithCounter(func(c int) error {
+			cn++
+			return repeat.HintTemporary(errPeanut)
+		}),
+		repeat.LimitRetryBudget(b),
+		repeat.StopOnSuccess(),
+	)
+	require.Equal(t, errPeanut, err)
+	require.Equal(t, 4, cn, "the first attempt and three retries")
+
+	// The budget is shared: no retries left for others.
+	cn = 0
+	require.Equal(t, errPeanut, repeat.Repeat(
+		repeat.Fn(func() error {
+			cn++
+			return repeat.HintTemporary(errPeanut)
+		}),
+		repeat.LimitRetryBudget(b),
+	))
+	require.Equal(t, 1, cn)
+
+	// Successes are deposited.
+	require.NoError(t, repeat.Once(repeat.Nope, repeat.LimitRetryBudget(b)))
+	require.InDelta(t, 0.1, b.Tokens(), 1e-9)
+}
+
+func TestRetryBudget_Concurrent(t *testing.T) {
+	b := repeat.NewRetryBudget(repeat.SetBudgetMaxTokens(100), repeat.SetBudgetMinPerSecond(0))
+
+	var mu sync.Mutex
+	withdrawn := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if b.Withdraw() {
+					mu.Lock()
+					withdrawn++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 100, withdrawn)
+}
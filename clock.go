@@ -0,0 +1,62 @@
+package repeat
+
+import (
+	"time"
+)
+
+// Clock provides the current time and timers. It allows to replace
+// the system time in WithDelay and other timing dependent operations,
+// e.g. by a manually advanced clock in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a new Timer that will send the current time
+	// on its channel after at least duration d.
+	NewTimer(d time.Duration) Timer
+
+	// After waits for the duration to elapse and then sends the
+	// current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Timer represents a single event the same way as time.Timer does.
+type Timer interface {
+	// C returns a channel the time is delivered on when the timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing. It returns false if the
+	// timer has already expired or been stopped.
+	Stop() bool
+}
+
+// SystemClock is a Clock that uses functions from the time package.
+//
+// It is the default Clock.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.t.Stop()
+}
@@ -0,0 +1,271 @@
+package repeat_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+var (
+	epoch     = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	errPeanut = errors.New("peanut")
+)
+
+// goOp calls op in a separate goroutine and returns a channel with
+// the result.
+func goOp(op repeat.Operation, e error) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- op(e)
+	}()
+
+	return ch
+}
+
+func TestSystemClock(t *testing.T) {
+	now := repeat.SystemClock.Now()
+	tm := repeat.SystemClock.NewTimer(time.Millisecond)
+	require.True(t, (<-tm.C()).After(now))
+	require.False(t, tm.Stop())
+	require.True(t, (<-repeat.SystemClock.After(time.Millisecond)).After(now))
+}
+
+func TestDelay_FakeClockBackoff(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(repeat.FixedBackoff(time.Hour).Set(), repeat.SetClock(c))
+
+	res := goOp(op, nil)
+	c.BlockUntil(2)
+	c.Advance(time.Hour - time.Nanosecond)
+	require.Len(t, res, 0)
+
+	c.Advance(time.Nanosecond)
+	require.NoError(t, <-res)
+	require.Equal(t, 0, c.Timers(), "all timers should be stopped")
+}
+
+func TestDelay_FakeClockErrorsTimeout(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(10*time.Second).Set(),
+		repeat.SetErrorsTimeout(25*time.Second),
+		repeat.SetClock(c),
+	)
+
+	for i := 0; i < 2; i++ {
+		res := goOp(op, repeat.HintTemporary(errPeanut))
+		c.BlockUntil(2)
+		c.Advance(10 * time.Second)
+		require.EqualError(t, <-res, "repeat.temporary: peanut")
+	}
+
+	// Only 5 seconds left until the deadline.
+	res := goOp(op, repeat.HintTemporary(errPeanut))
+	c.BlockUntil(2)
+	c.Advance(5 * time.Second)
+	require.Equal(t, errPeanut, <-res)
+}
+
+func TestDelay_FakeClockSuccessShiftsDeadline(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(10*time.Second).Set(),
+		repeat.SetErrorsTimeout(15*time.Second),
+		repeat.SetClock(c),
+	)
+
+	for _, e := range []error{repeat.HintTemporary(errPeanut), nil, repeat.HintTemporary(errPeanut)} {
+		res := goOp(op, e)
+		c.BlockUntil(2)
+		c.Advance(10 * time.Second)
+		require.Equal(t, e, <-res)
+	}
+}
+
+func TestDelay_FakeClockCancel(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	ctx, cancel := context.WithCancel(context.Background())
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(time.Hour).Set(),
+		repeat.SetContext(ctx),
+		repeat.SetClock(c),
+	)
+
+	res := goOp(op, nil)
+	c.BlockUntil(2)
+	cancel()
+	require.Equal(t, context.Canceled, <-res)
+}
+
+func TestDelay_BackoffIsCumulativeByDefault(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(repeat.ExponentialBackoff(time.Second).Set(), repeat.SetClock(c))
+
+	for _, d := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		waitDelay(t, c, op, nil, d)
+	}
+}
+
+func TestDelay_BackoffResetOnSuccess(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.ExponentialBackoff(time.Second).Set(),
+		repeat.SetBackoffResetOnSuccess(),
+		repeat.SetClock(c),
+	)
+
+	for _, step := range []struct {
+		e error
+		d time.Duration
+	}{
+		{repeat.HintTemporary(errPeanut), time.Second},
+		{repeat.HintTemporary(errPeanut), 2 * time.Second},
+		{repeat.HintTemporary(errPeanut), 4 * time.Second},
+		{nil, time.Second},
+		{repeat.HintTemporary(errPeanut), 2 * time.Second},
+		{nil, time.Second},
+		{nil, time.Second},
+	} {
+		waitDelay(t, c, op, step.e, step.d)
+	}
+}
+
+// waitDelay checks that op called with e waits exactly d on c.
+func waitDelay(t *testing.T, c *repeattest.FakeClock, op repeat.Operation, e error, d time.Duration) {
+	res := goOp(op, e)
+	c.BlockUntil(2)
+	c.Advance(d - time.Nanosecond)
+	require.Len(t, res, 0)
+	c.Advance(time.Nanosecond)
+	require.Equal(t, e, <-res)
+}
+
+func TestDelay_RetryAfterOverride(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(repeat.ExponentialBackoff(time.Second).Set(), repeat.SetClock(c))
+
+	waitDelay(t, c, op, repeat.HintTemporaryAfter(errPeanut, time.Minute), time.Minute)
+	waitDelay(t, c, op, repeat.HintTemporaryAfter(errPeanut, time.Millisecond), time.Millisecond)
+	// The backoff sequence is not affected by suggested delays.
+	waitDelay(t, c, op, repeat.HintTemporary(errPeanut), time.Second)
+}
+
+func TestDelay_RetryAfterFloor(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.ExponentialBackoff(time.Second).Set(),
+		repeat.SetRetryAfterMode(repeat.RetryAfterFloor),
+		repeat.SetClock(c),
+	)
+
+	waitDelay(t, c, op, repeat.HintTemporaryAfter(errPeanut, time.Minute), time.Minute)
+	waitDelay(t, c, op, repeat.HintTemporaryAfter(errPeanut, time.Millisecond), 2*time.Second)
+}
+
+func TestDelay_RetryAfterIgnore(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(time.Second).Set(),
+		repeat.SetRetryAfterMode(repeat.RetryAfterIgnore),
+		repeat.SetClock(c),
+	)
+
+	waitDelay(t, c, op, repeat.HintTemporaryAfter(errPeanut, time.Minute), time.Second)
+}
+
+func TestDelay_RetryAfterRespectsErrorsTimeout(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(time.Second).Set(),
+		repeat.SetErrorsTimeout(10*time.Second),
+		repeat.SetClock(c),
+	)
+
+	res := goOp(op, repeat.HintTemporaryAfter(errPeanut, time.Minute))
+	c.BlockUntil(2)
+	c.Advance(10 * time.Second)
+	require.Equal(t, errPeanut, <-res)
+}
+
+func TestDelay_StopBeforeDeadline(t *testing.T) {
+	now := time.Now()
+	c := repeattest.NewFakeClock(now)
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(2*time.Second))
+	defer cancel()
+
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(30*time.Second).Set(),
+		repeat.SetContext(ctx),
+		repeat.SetStopBeforeDeadline(),
+		repeat.SetClock(c),
+	)
+
+	// No timers are started, the delay is not waited.
+	require.Equal(t, errPeanut, op(repeat.HintTemporary(errPeanut)))
+	require.Equal(t, context.DeadlineExceeded, op(nil))
+	require.Equal(t, 0, c.Timers())
+}
+
+func TestDelay_StopBeforeDeadlineHintStop(t *testing.T) {
+	now := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(2*time.Second))
+	defer cancel()
+
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(30*time.Second).Set(),
+		repeat.SetContext(ctx),
+		repeat.SetContextHintStop(),
+		repeat.SetStopBeforeDeadline(),
+		repeat.SetClock(repeattest.NewFakeClock(now)),
+	)
+	require.EqualError(t, op(nil), "repeat.stop")
+}
+
+func TestDelay_StopBeforeDeadlineInTime(t *testing.T) {
+	now := time.Now()
+	c := repeattest.NewFakeClock(now)
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Hour))
+	defer cancel()
+
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(30*time.Second).Set(),
+		repeat.SetContext(ctx),
+		repeat.SetStopBeforeDeadline(),
+		repeat.SetClock(c),
+	)
+	waitDelay(t, c, op, repeat.HintTemporary(errPeanut), 30*time.Second)
+}
+
+func TestDelay_MaxElapsed(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(30*time.Second).Set(),
+		repeat.SetMaxElapsed(time.Minute),
+		repeat.SetClock(c),
+	)
+
+	// The limit is counted since WithDelay is created.
+	c.Advance(10 * time.Second)
+	waitDelay(t, c, op, repeat.HintTemporary(errPeanut), 30*time.Second)
+
+	// No timers are started, the delay would end after the limit.
+	require.Equal(t, errPeanut, op(repeat.HintTemporary(errPeanut)))
+	require.EqualError(t, op(nil), "repeat.stop")
+	require.Equal(t, 0, c.Timers())
+}
+
+func TestDelay_StopBeforeDeadlineNoDeadline(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := repeat.WithDelay(
+		repeat.FixedBackoff(30*time.Second).Set(),
+		repeat.SetStopBeforeDeadline(),
+		repeat.SetClock(c),
+	)
+	waitDelay(t, c, op, nil, 30*time.Second)
+}
@@ -0,0 +1,172 @@
+// Command repeat-sim samples a backoff algorithm many times and prints
+// statistics of the delays it produces.
+//
+// Usage:
+//
+//	repeat-sim [flags]
+//
+// Example:
+//
+//	repeat-sim -backoff 'fulljitter(500ms,max=10s)' -attempts 10 -hist 5
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ssgreg/repeat"
+)
+
+type config struct {
+	backoff  string
+	attempts int
+	runs     int
+	format   string
+	hist     int
+	buckets  int
+	width    int
+}
+
+func main() {
+	var cfg config
+
+	fs := flag.NewFlagSet("repeat-sim", flag.ExitOnError)
+	fs.StringVar(&cfg.backoff, "backoff", "exp(initial=100ms,max=10s)", "backoff in the compact syntax, e.g. fulljitter(500ms,max=10s)")
+	fs.IntVar(&cfg.attempts, "attempts", 10, "number of attempts to simulate")
+	fs.IntVar(&cfg.runs, "runs", 10000, "number of independent runs")
+	fs.StringVar(&cfg.format, "format", "table", "output format: table or csv")
+	fs.IntVar(&cfg.hist, "hist", 0, "print a histogram of delays after the given attempt, starting from 1")
+	fs.IntVar(&cfg.buckets, "buckets", 20, "number of histogram buckets")
+	fs.IntVar(&cfg.width, "width", 40, "width of bars")
+	_ = fs.Parse(os.Args[1:])
+
+	if err := run(cfg, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "repeat-sim: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+func run(cfg config, w io.Writer) error {
+	b, err := repeat.ParseBackoff(cfg.backoff)
+	if err != nil {
+		return err
+	}
+	if cfg.attempts <= 0 || cfg.runs <= 0 {
+		return fmt.Errorf("attempts and runs should be positive")
+	}
+	if cfg.hist < 0 || cfg.hist > cfg.attempts {
+		return fmt.Errorf("histogram attempt should be in range [1..%d]", cfg.attempts)
+	}
+
+	s := repeat.SimulateBackoff(b, cfg.attempts, cfg.runs)
+	switch cfg.format {
+	case "table":
+		fmt.Fprintf(w, "%v, %d runs\n\n", b, cfg.runs)
+		writeTable(w, s.Stats(), cfg.width)
+	case "csv":
+		err = writeCSV(w, s.Stats())
+	default:
+		return fmt.Errorf("unknown format %q", cfg.format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cfg.hist > 0 {
+		fmt.Fprintf(w, "\nDelays after attempt %d:\n\n", cfg.hist)
+		bounds, counts := s.Histogram(cfg.hist-1, cfg.buckets)
+		writeHistogram(w, bounds, counts, cfg.width)
+	}
+
+	return nil
+}
+
+// writeTable writes stats as a table with a bar showing the median
+// delay relative to the maximum one.
+func writeTable(w io.Writer, stats []repeat.DelayStats, width int) {
+	var max time.Duration
+	for _, s := range stats {
+		if s.Max > max {
+			max = s.Max
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "attempt\tmin\tmedian\tp99\tmax\ttotal median\ttotal p99\t")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%d\t%v\t%v\t%v\t%v\t%v\t%v\t%s\n",
+			s.Attempt+1, round(s.Min), round(s.Median), round(s.P99), round(s.Max),
+			round(s.CumulativeMedian), round(s.CumulativeP99), bar(s.Median, max, width))
+	}
+	_ = tw.Flush()
+}
+
+func writeCSV(w io.Writer, stats []repeat.DelayStats) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"attempt", "min", "median", "p99", "max", "total_median", "total_p99"})
+	for _, s := range stats {
+		_ = cw.Write([]string{
+			strconv.Itoa(s.Attempt + 1),
+			seconds(s.Min), seconds(s.Median), seconds(s.P99), seconds(s.Max),
+			seconds(s.CumulativeMedian), seconds(s.CumulativeP99),
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeHistogram(w io.Writer, bounds []time.Duration, counts []int, width int) {
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, c := range counts {
+		fmt.Fprintf(tw, "<= %v\t%d\t%s\n", round(bounds[i]), c, bar(time.Duration(c), time.Duration(max), width))
+	}
+	_ = tw.Flush()
+}
+
+// bar returns a bar of v relative to max. It is scaled in float64 since
+// width*v can overflow time.Duration.
+func bar(v, max time.Duration, width int) string {
+	if max <= 0 {
+		return ""
+	}
+
+	n := int(float64(width) * float64(v) / float64(max))
+	switch {
+	case n < 0:
+		n = 0
+	case n > width:
+		n = width
+	}
+
+	return strings.Repeat("#", n)
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	default:
+		return d
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Table(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, run(config{
+		backoff:  "fixed(1s)",
+		attempts: 2,
+		runs:     10,
+		format:   "table",
+		hist:     1,
+		buckets:  2,
+		width:    4,
+	}, &out))
+
+	require.Equal(t, `fixed(1s), 10 runs
+
+attempt  min  median  p99  max  total median  total p99  
+1        1s   1s      1s   1s   1s            1s         ####
+2        1s   1s      1s   1s   2s            2s         ####
+
+Delays after attempt 1:
+
+<= 1s  10  ####
+<= 1s  0   
+`, out.String())
+}
+
+func TestRun_CSV(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, run(config{
+		backoff:  "exp(initial=100ms)",
+		attempts: 3,
+		runs:     10,
+		format:   "csv",
+	}, &out))
+
+	require.Equal(t, `attempt,min,median,p99,max,total_median,total_p99
+1,0.1,0.1,0.1,0.1,0.1,0.1
+2,0.2,0.2,0.2,0.2,0.3,0.3
+3,0.4,0.4,0.4,0.4,0.7,0.7
+`, out.String())
+}
+
+func TestRun_LargeDelays(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, run(config{
+		backoff:  "exp(1s,max=200000h)",
+		attempts: 50,
+		runs:     10,
+		format:   "table",
+		width:    40,
+	}, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	last := lines[len(lines)-1]
+	require.Contains(t, last, "2562047h47m16.854775807s", "the total wait should saturate")
+	require.True(t, strings.HasSuffix(last, " "+strings.Repeat("#", 40)), last)
+}
+
+func TestBar(t *testing.T) {
+	const maxDuration = time.Duration(1<<63 - 1)
+	require.Equal(t, "", bar(time.Second, 0, 4))
+	require.Equal(t, "", bar(0, time.Second, 4))
+	require.Equal(t, "##", bar(time.Second, 2*time.Second, 4))
+	require.Equal(t, "####", bar(maxDuration, maxDuration, 4))
+	require.Equal(t, "##", bar(maxDuration/2, maxDuration, 4))
+	require.Equal(t, "####", bar(maxDuration, time.Second, 4))
+	require.Equal(t, "", bar(-maxDuration, time.Second, 4))
+}
+
+func TestRun_Errors(t *testing.T) {
+	for _, cfg := range []config{
+		{backoff: "linear(1s)", attempts: 1, runs: 1, format: "table"},
+		{backoff: "fixed(1s)", attempts: 0, runs: 1, format: "table"},
+		{backoff: "fixed(1s)", attempts: 1, runs: 1, format: "json"},
+		{backoff: "fixed(1s)", attempts: 1, runs: 1, format: "table", hist: 2},
+	} {
+		require.Error(t, run(cfg, &bytes.Buffer{}), "%+v", cfg)
+	}
+}
@@ -0,0 +1,233 @@
+// Command repeat runs a command until it succeeds or a policy gives up.
+//
+// Usage:
+//
+//	repeat [flags] -- command [args...]
+//
+// Example:
+//
+//	repeat -tries 5 -backoff exp -delay 500ms -max-delay 10s -- curl -f http://localhost/health
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ssgreg/repeat"
+)
+
+// exitCodes is a flag.Value with a comma-separated list of exit codes.
+type exitCodes map[int]bool
+
+func (c exitCodes) String() string {
+	var codes []int
+	for code := range c {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var s []string
+	for _, code := range codes {
+		s = append(s, strconv.Itoa(code))
+	}
+
+	return strings.Join(s, ",")
+}
+
+func (c exitCodes) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("bad exit code %q", v)
+		}
+		c[code] = true
+	}
+
+	return nil
+}
+
+type config struct {
+	tries         int
+	backoff       string
+	delay         time.Duration
+	maxDelay      time.Duration
+	errorsTimeout time.Duration
+	timeout       time.Duration
+	temporary     exitCodes
+	fatal         exitCodes
+	showFailed    bool
+}
+
+func main() {
+	cfg := config{temporary: exitCodes{}, fatal: exitCodes{}}
+
+	fs := flag.NewFlagSet("repeat", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: repeat [flags] -- command [args...]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.IntVar(&cfg.tries, "tries", 3, "maximum number of attempts, 0 means no limit")
+	fs.StringVar(&cfg.backoff, "backoff", "fixed", "backoff kind: fixed, exp or fulljitter")
+	fs.DurationVar(&cfg.delay, "delay", time.Second, "fixed delay or base delay of the backoff")
+	fs.DurationVar(&cfg.maxDelay, "max-delay", 0, "maximum delay of exp and fulljitter backoff, 0 means no limit")
+	fs.DurationVar(&cfg.errorsTimeout, "errors-timeout", 0, "give up if the command keeps failing for this time, 0 means no limit")
+	fs.DurationVar(&cfg.timeout, "timeout", 0, "overall timeout including all attempts and delays, 0 means no limit")
+	fs.Var(cfg.temporary, "temporary", "comma-separated exit codes to retry, all non-zero codes by default")
+	fs.Var(cfg.fatal, "fatal", "comma-separated exit codes to give up on immediately")
+	fs.BoolVar(&cfg.showFailed, "show-failed", true, "show stdout and stderr of failed attempts")
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	os.Exit(run(cfg, fs.Args(), os.Stdout, os.Stderr))
+}
+
+// run repeats the command and returns the exit code for the process.
+func run(cfg config, args []string, stdout, stderr io.Writer) int {
+	backoff, err := backoffOption(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "repeat: %v\n", err)
+		return 2
+	}
+
+	ctx := context.Background()
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+
+	options := []func(*repeat.DelayOptions){backoff, repeat.SetContext(ctx)}
+	if cfg.errorsTimeout > 0 {
+		options = append(options, repeat.SetErrorsTimeout(cfg.errorsTimeout))
+	}
+
+	var last *output
+	attempt := 0
+	ops := []repeat.Operation{
+		func(e error) error {
+			attempt++
+			out, err := runCommand(ctx, args, cfg.showFailed, stdout, stderr)
+			last = out
+
+			err = classify(cfg, err)
+			if repeat.IsTemporary(err) {
+				fmt.Fprintf(stderr, "repeat: attempt %d failed: %v\n", attempt, repeat.Cause(err))
+			}
+
+			return err
+		},
+		repeat.StopOnSuccess(),
+	}
+	if cfg.tries > 0 {
+		// The limit precedes WithDelay, so there is no delay after the
+		// last try.
+		ops = append(ops, repeat.LimitMaxAttempts(cfg.tries))
+	}
+	ops = append(ops, repeat.WithDelay(options...))
+
+	err = repeat.Repeat(ops...)
+	if err == nil {
+		return 0
+	}
+
+	if !cfg.showFailed {
+		// Show at least the output of the last attempt.
+		last.writeTo(stdout, stderr)
+	}
+	fmt.Fprintf(stderr, "repeat: giving up after %d attempt(s): %v\n", attempt, err)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+
+	return 1
+}
+
+// backoffOption creates WithDelay' backoff option using the flags.
+func backoffOption(cfg config) (func(*repeat.DelayOptions), error) {
+	switch cfg.backoff {
+	case "fixed":
+		return repeat.FixedBackoff(cfg.delay).Set(), nil
+	case "exp":
+		b := repeat.ExponentialBackoff(cfg.delay)
+		if cfg.maxDelay > 0 {
+			b.WithMaxDelay(cfg.maxDelay)
+		}
+		return b.Set(), nil
+	case "fulljitter":
+		b := repeat.FullJitterBackoff(cfg.delay)
+		if cfg.maxDelay > 0 {
+			b.WithMaxDelay(cfg.maxDelay)
+		}
+		return b.Set(), nil
+	default:
+		return nil, fmt.Errorf("unknown backoff %q", cfg.backoff)
+	}
+}
+
+// classify hints the error of a command run using exit codes from the
+// flags. Errors that are not related to exit codes, e.g. a missing
+// binary, are fatal.
+func classify(cfg config, err error) error {
+	var exitErr *exec.ExitError
+	if err == nil || !errors.As(err, &exitErr) {
+		return err
+	}
+
+	code := exitErr.ExitCode()
+	switch {
+	case cfg.fatal[code]:
+		return err
+	case len(cfg.temporary) == 0 || cfg.temporary[code]:
+		return repeat.HintTemporary(err)
+	default:
+		return err
+	}
+}
+
+// output holds stdout and stderr of a command run.
+type output struct {
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+func (o *output) writeTo(stdout, stderr io.Writer) {
+	_, _ = o.stdout.WriteTo(stdout)
+	_, _ = o.stderr.WriteTo(stderr)
+}
+
+// runCommand runs the command once. If the output of failed attempts
+// is not shown, it is buffered and written only in case of success.
+func runCommand(ctx context.Context, args []string, showFailed bool, stdout, stderr io.Writer) (*output, error) {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+
+	out := &output{}
+	if showFailed {
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		return out, cmd.Run()
+	}
+
+	cmd.Stdout, cmd.Stderr = &out.stdout, &out.stderr
+	err := cmd.Run()
+	if err == nil {
+		out.writeTo(stdout, stderr)
+	}
+
+	return out, err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+)
+
+func testConfig() config {
+	return config{
+		tries:      3,
+		backoff:    "fixed",
+		delay:      time.Millisecond,
+		temporary:  exitCodes{},
+		fatal:      exitCodes{},
+		showFailed: true,
+	}
+}
+
+func TestRun_Success(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, run(testConfig(), []string{"sh", "-c", "echo ok"}, &stdout, &stderr))
+	require.Equal(t, "ok\n", stdout.String())
+	require.Empty(t, stderr.String())
+}
+
+func TestRun_Tries(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 3, run(testConfig(), []string{"sh", "-c", "echo fail; exit 3"}, &stdout, &stderr))
+	require.Equal(t, "fail\nfail\nfail\n", stdout.String())
+	require.Contains(t, stderr.String(), "repeat: attempt 2 failed: exit status 3")
+	require.Contains(t, stderr.String(), "repeat: giving up after 3 attempt(s): exit status 3")
+}
+
+func TestRun_NoDelayAfterLastTry(t *testing.T) {
+	cfg := testConfig()
+	cfg.tries = 1
+	cfg.delay = time.Hour
+	cfg.timeout = 10 * time.Second
+
+	// A delay after the last try would end with the timeout.
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 3, run(cfg, []string{"sh", "-c", "exit 3"}, &stdout, &stderr))
+	require.Contains(t, stderr.String(), "repeat: giving up after 1 attempt(s): exit status 3")
+}
+
+func TestRun_HideFailed(t *testing.T) {
+	cfg := testConfig()
+	cfg.showFailed = false
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 3, run(cfg, []string{"sh", "-c", "echo fail; exit 3"}, &stdout, &stderr))
+	require.Equal(t, "fail\n", stdout.String(), "only the last attempt should be shown")
+}
+
+func TestRun_Fatal(t *testing.T) {
+	cfg := testConfig()
+	require.NoError(t, cfg.fatal.Set("4"))
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 4, run(cfg, []string{"sh", "-c", "echo fail; exit 4"}, &stdout, &stderr))
+	require.Equal(t, "fail\n", stdout.String())
+}
+
+func TestRun_Timeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.tries = 0
+	cfg.timeout = 50 * time.Millisecond
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 1, run(cfg, []string{"sh", "-c", "exit 1"}, &stdout, &stderr))
+	require.Contains(t, stderr.String(), "context deadline exceeded")
+}
+
+func TestRun_BadBackoff(t *testing.T) {
+	cfg := testConfig()
+	cfg.backoff = "linear"
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 2, run(cfg, []string{"true"}, &stdout, &stderr))
+}
+
+func TestClassify(t *testing.T) {
+	exit := func(code string) error {
+		return exec.Command("sh", "-c", "exit "+code).Run()
+	}
+
+	cfg := testConfig()
+	require.NoError(t, classify(cfg, nil))
+	require.True(t, repeat.IsTemporary(classify(cfg, exit("1"))))
+	require.False(t, repeat.IsTemporary(classify(cfg, exec.ErrNotFound)))
+
+	require.NoError(t, cfg.temporary.Set("1, 2"))
+	require.NoError(t, cfg.fatal.Set("2"))
+	require.True(t, repeat.IsTemporary(classify(cfg, exit("1"))))
+	require.False(t, repeat.IsTemporary(classify(cfg, exit("2"))))
+	require.False(t, repeat.IsTemporary(classify(cfg, exit("3"))))
+
+	require.Error(t, cfg.fatal.Set("x"))
+}
@@ -2,6 +2,7 @@ package repeat
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -16,6 +17,17 @@ func SetErrorsTimeout(t time.Duration) func(*DelayOptions) {
 	}
 }
 
+// SetMaxElapsed limits the time of repetition since WithDelay is
+// created. The repetition is stopped with the last cause instead of
+// waiting if the delay would end after the limit.
+//
+// Default value is 0 that means no limit.
+func SetMaxElapsed(d time.Duration) func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.MaxElapsed = d
+	}
+}
+
 // SetContext allows to set a context instead of default one.
 func SetContext(ctx context.Context) func(*DelayOptions) {
 	return func(do *DelayOptions) {
@@ -31,25 +43,164 @@ func SetContextHintStop() func(*DelayOptions) {
 	}
 }
 
+// SetStopBeforeDeadline instructs to stop immediately instead of
+// waiting if the delay would exceed the context deadline. The
+// repetition is stopped with the last cause or, if there is no one,
+// the same way as in case of context expiration.
+func SetStopBeforeDeadline() func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.StopBeforeDeadline = true
+	}
+}
+
+// SetBackoffResetOnSuccess instructs to reset the backoff sequence
+// each time the repetition operation is successfully completed.
+//
+// By default the backoff sequence is cumulative and keeps its state
+// between successes.
+func SetBackoffResetOnSuccess() func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.BackoffResetOnSuccess = true
+	}
+}
+
+// RetryAfterMode specifies how WithDelay treats a delay suggested by
+// HintTemporaryAfter.
+type RetryAfterMode int
+
+const (
+	// RetryAfterOverride waits the suggested delay instead of the
+	// backoff one.
+	RetryAfterOverride RetryAfterMode = iota
+
+	// RetryAfterFloor waits the suggested delay if it is greater than
+	// the backoff one.
+	RetryAfterFloor
+
+	// RetryAfterIgnore ignores the suggested delay.
+	RetryAfterIgnore
+)
+
+func (m RetryAfterMode) String() string {
+	switch m {
+	case RetryAfterOverride:
+		return "override"
+	case RetryAfterFloor:
+		return "floor"
+	case RetryAfterIgnore:
+		return "ignore"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m RetryAfterMode) MarshalText() ([]byte, error) {
+	if m.String() == "unknown" {
+		return nil, fmt.Errorf("repeat: unknown retry-after mode %d", int(m))
+	}
+
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *RetryAfterMode) UnmarshalText(text []byte) error {
+	for _, v := range []RetryAfterMode{RetryAfterOverride, RetryAfterFloor, RetryAfterIgnore} {
+		if v.String() == string(text) {
+			*m = v
+			return nil
+		}
+	}
+
+	return fmt.Errorf("repeat: unknown retry-after mode %q", text)
+}
+
+// SetRetryAfterMode specifies how to treat a delay suggested by
+// HintTemporaryAfter.
+//
+// Default value is RetryAfterOverride.
+func SetRetryAfterMode(m RetryAfterMode) func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.RetryAfterMode = m
+	}
+}
+
+// SetObserver allows to report chosen delays to the given Observer.
+func SetObserver(o Observer) func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.Observer = o
+	}
+}
+
+// SetClock allows to set a clock instead of the system one.
+func SetClock(c Clock) func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.Clock = c
+	}
+}
+
+// SetHandle allows the given Handle to interrupt delays. Stop of the
+// handle stops the repetition with HintStop(nil) and TriggerNow skips
+// the current delay.
+func SetHandle(h *Handle) func(*DelayOptions) {
+	return func(do *DelayOptions) {
+		do.Handle = h
+	}
+}
+
 // WithDelay constructs HeartbeatPredicate.
 func WithDelay(options ...func(hb *DelayOptions)) Operation {
 	do := applyOptions(applyOptions(&DelayOptions{}, defaultOptions()), options)
 
 	shift := func() time.Time {
-		return time.Now().Add(do.ErrorsTimeout)
+		return do.Clock.Now().Add(do.ErrorsTimeout)
 	}
 
 	deadline := shift()
 
+	var limit time.Time
+	if do.MaxElapsed > 0 {
+		limit = do.Clock.Now().Add(do.MaxElapsed)
+	}
+
 	return func(e error) error {
-		// Shift the deadline in case of success.
+		// Shift the deadline and start the backoff sequence over in
+		// case of success.
 		if e == nil {
 			deadline = shift()
+			if do.BackoffResetOnSuccess && do.Resettable != nil {
+				do.Resettable.Reset()
+			}
+		}
+
+		delay := do.nextDelay(e)
+		if do.StopBeforeDeadline && do.exceedsContextDeadline(delay) {
+			// The context will expire before the delay ends. Let our
+			// caller to take care of the last error right now.
+			if e != nil {
+				return Cause(e)
+			}
+			if do.ContextHintStop {
+				return HintStop(nil)
+			}
+
+			return context.DeadlineExceeded
 		}
+		if !limit.IsZero() && do.Clock.Now().Add(delay).After(limit) {
+			// The limit will be reached before the delay ends.
+			if e != nil {
+				return Cause(e)
+			}
 
-		delayT := time.NewTimer(do.Backoff())
+			return HintStop(nil)
+		}
+		if do.Observer != nil {
+			do.Observer.OnDelay(delay, e)
+		}
+
+		delayT := do.Clock.NewTimer(delay)
 		defer delayT.Stop()
-		deadlineT := time.NewTimer(deadline.Sub(time.Now()))
+		deadlineT := do.Clock.NewTimer(deadline.Sub(do.Clock.Now()))
 		defer deadlineT.Stop()
 
 		select {
@@ -61,12 +212,18 @@ func WithDelay(options ...func(hb *DelayOptions)) Operation {
 
 			return do.Context.Err()
 
-		case <-deadlineT.C:
+		case <-do.Handle.stopped():
+			return HintStop(nil)
+
+		case <-do.Handle.triggered():
+			return e
+
+		case <-deadlineT.C():
 			// The reason of a deadline is the previous error. Let our
 			// caller to take care of it.
 			return Cause(e)
 
-		case <-delayT.C:
+		case <-delayT.C():
 			return e
 		}
 	}
@@ -74,17 +231,67 @@ func WithDelay(options ...func(hb *DelayOptions)) Operation {
 
 // DelayOptions holds parameters for a heartbeat process.
 type DelayOptions struct {
-	ErrorsTimeout   time.Duration
-	Backoff         func() time.Duration
-	Context         context.Context
-	ContextHintStop bool
+	ErrorsTimeout time.Duration
+	MaxElapsed    time.Duration
+	Backoff       func() time.Duration
+
+	// Resettable is the sequence Backoff is taken from. It is set by
+	// backoff builders and allows SetBackoffResetOnSuccess to work. An
+	// option that sets Backoff directly should set Resettable to nil,
+	// otherwise a sequence that is not used anymore is reset. Reset on
+	// success does nothing without Resettable.
+	Resettable            Backoff
+	BackoffResetOnSuccess bool
+	RetryAfterMode        RetryAfterMode
+	Context               context.Context
+	ContextHintStop       bool
+	StopBeforeDeadline    bool
+	Clock                 Clock
+	Observer              Observer
+	Handle                *Handle
+}
+
+// nextDelay returns a delay before the next repetition taking into
+// account the delay suggested by the passed error.
+func (do *DelayOptions) nextDelay(e error) time.Duration {
+	hint, ok := RetryAfter(e)
+	if !ok || do.RetryAfterMode == RetryAfterIgnore {
+		return do.Backoff()
+	}
+
+	if do.RetryAfterMode == RetryAfterFloor {
+		if delay := do.Backoff(); delay > hint {
+			return delay
+		}
+	}
+
+	return hint
+}
+
+// setBackoff sets both Backoff and Resettable using the given sequence.
+func (do *DelayOptions) setBackoff(b Backoff) {
+	do.Backoff = b.Next
+	do.Resettable = b
+}
+
+// exceedsContextDeadline checks if the delay ends after the context
+// deadline.
+func (do *DelayOptions) exceedsContextDeadline(delay time.Duration) bool {
+	deadline, ok := do.Context.Deadline()
+
+	return ok && do.Clock.Now().Add(delay).After(deadline)
 }
 
 func defaultOptions() []func(hb *DelayOptions) {
 	return []func(do *DelayOptions){
 		SetContext(context.Background()),
 		SetErrorsTimeout(1<<63 - 1),
-		FixedBackoff(time.Second).Set(),
+		SetClock(SystemClock),
+		// A fixed delay does not need a resettable sequence, so options
+		// that set only Backoff do not leave a stale one.
+		func(do *DelayOptions) {
+			do.Backoff = FixedBackoffAlgorithm(time.Second)
+		},
 	}
 }
 
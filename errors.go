@@ -1,10 +1,19 @@
 package repeat
 
+import (
+	"errors"
+	"time"
+)
+
 // TemporaryError allows not to stop repetitions process right now.
 //
 // This error never returns to the caller as is, only wrapped error.
 type TemporaryError struct {
 	Cause error
+
+	// RetryAfter is a delay suggested for the next repetition, e.g.
+	// by a server. Zero value means no suggestion.
+	RetryAfter time.Duration
 }
 
 func (e *TemporaryError) Error() string {
@@ -16,14 +25,40 @@ func (e *TemporaryError) Error() string {
 	return r
 }
 
+// Unwrap returns the cause of TemporaryError.
+func (e *TemporaryError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *TemporaryError) cause() error {
+	return e.Cause
+}
+
 // HintTemporary makes a TemporaryError.
 func HintTemporary(e error) error {
-	return &TemporaryError{Cause(e)}
+	return &TemporaryError{Cause: Cause(e)}
 }
 
-// IsTemporary checks if passed error is TemporaryError.
+// HintTemporaryAfter makes a TemporaryError with a delay suggested for
+// the next repetition.
+func HintTemporaryAfter(e error, d time.Duration) error {
+	return &TemporaryError{Cause: Cause(e), RetryAfter: d}
+}
+
+// RetryAfter returns the delay suggested by HintTemporaryAfter if passed
+// error is TemporaryError with a positive delay.
+func RetryAfter(e error) (time.Duration, bool) {
+	t, ok := asHint(e).(*TemporaryError)
+	if !ok || t.RetryAfter <= 0 {
+		return 0, false
+	}
+
+	return t.RetryAfter, true
+}
+
+// IsTemporary checks if passed error is TemporaryError or wraps it.
 func IsTemporary(e error) bool {
-	switch e.(type) {
+	switch asHint(e).(type) {
 	case *TemporaryError:
 		return true
 	default:
@@ -48,14 +83,23 @@ func (e *StopError) Error() string {
 	return r
 }
 
+// Unwrap returns the cause of StopError.
+func (e *StopError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *StopError) cause() error {
+	return e.Cause
+}
+
 // HintStop makes a StopError.
 func HintStop(e error) error {
-	return &StopError{Cause(e)}
+	return &StopError{Cause: Cause(e)}
 }
 
-// IsStop checks if passed error is StopError.
+// IsStop checks if passed error is StopError or wraps it.
 func IsStop(e error) bool {
-	switch e.(type) {
+	switch asHint(e).(type) {
 	case *StopError:
 		return true
 	default:
@@ -64,14 +108,28 @@ func IsStop(e error) bool {
 }
 
 // Cause extracts the cause error from TemporaryError and StopError
-// or return the passed one.
+// (even wrapped ones) or return the passed one.
 func Cause(err error) error {
-	switch e := err.(type) {
-	case *TemporaryError:
-		return e.Cause
-	case *StopError:
-		return e.Cause
-	default:
-		return err
+	if h := asHint(err); h != nil {
+		return h.cause()
 	}
+
+	return err
+}
+
+// hint is implemented by TemporaryError and StopError.
+type hint interface {
+	error
+	cause() error
+}
+
+// asHint finds the first TemporaryError or StopError in the err's
+// chain. It returns nil if there is no one.
+func asHint(err error) hint {
+	var h hint
+	if errors.As(err, &h) {
+		return h
+	}
+
+	return nil
 }
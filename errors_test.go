@@ -2,7 +2,9 @@ package repeat
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -30,3 +32,63 @@ func TestTemporaryError(t *testing.T) {
 	require.EqualError(t, e, "repeat.temporary")
 	require.EqualError(t, HintTemporary(errors.New("internal")), "repeat.temporary: internal")
 }
+
+func TestHintTemporaryAfter(t *testing.T) {
+	e := HintTemporaryAfter(errors.New("busy"), time.Minute)
+	require.True(t, IsTemporary(e))
+	require.EqualError(t, e, "repeat.temporary: busy")
+	require.EqualError(t, Cause(e), "busy")
+
+	d, ok := RetryAfter(e)
+	require.True(t, ok)
+	require.Equal(t, time.Minute, d)
+
+	_, ok = RetryAfter(HintTemporary(errors.New("busy")))
+	require.False(t, ok)
+	_, ok = RetryAfter(errors.New("busy"))
+	require.False(t, ok)
+	_, ok = RetryAfter(nil)
+	require.False(t, ok)
+}
+
+func TestWrappedStopError(t *testing.T) {
+	internal := errors.New("internal")
+	e := fmt.Errorf("context: %w", HintStop(internal))
+	require.True(t, IsStop(e))
+	require.False(t, IsTemporary(e))
+	require.Equal(t, internal, Cause(e))
+	require.True(t, errors.Is(e, internal))
+
+	var stop *StopError
+	require.True(t, errors.As(e, &stop))
+	require.Equal(t, internal, stop.Cause)
+}
+
+func TestWrappedTemporaryError(t *testing.T) {
+	internal := errors.New("internal")
+	e := fmt.Errorf("context: %w", HintTemporaryAfter(internal, time.Second))
+	require.True(t, IsTemporary(e))
+	require.False(t, IsStop(e))
+	require.Equal(t, internal, Cause(e))
+	require.True(t, errors.Is(e, internal))
+
+	d, ok := RetryAfter(e)
+	require.True(t, ok)
+	require.Equal(t, time.Second, d)
+}
+
+func TestUnwrap(t *testing.T) {
+	internal := errors.New("internal")
+	require.Equal(t, internal, errors.Unwrap(HintStop(internal)))
+	require.Equal(t, internal, errors.Unwrap(HintTemporary(internal)))
+	require.Nil(t, errors.Unwrap(HintStop(nil)))
+	require.True(t, errors.Is(HintTemporary(internal), internal))
+}
+
+func TestCause_OutermostHintWins(t *testing.T) {
+	internal := errors.New("internal")
+	e := &StopError{Cause: fmt.Errorf("wrapped: %w", HintTemporary(internal))}
+	require.True(t, IsStop(e))
+	require.False(t, IsTemporary(e))
+	require.EqualError(t, Cause(e), "wrapped: repeat.temporary: internal")
+}
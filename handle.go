@@ -0,0 +1,149 @@
+package repeat
+
+import (
+	"sync"
+)
+
+// Handle controls a repetition process running in background. Create
+// it with NewHandle, pass it to WithDelay using SetHandle option and
+// start the repetition with Start, e.g.:
+//
+//	h := repeat.NewHandle()
+//	h.Start(op, repeat.WithDelay(repeat.SetHandle(h)))
+//
+// Without SetHandle, Stop and TriggerNow cannot interrupt delays: Stop
+// waits for the current delay to end and TriggerNow does nothing.
+//
+// Handle is safe for concurrent use.
+type Handle struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	trigger  chan struct{}
+	done     chan struct{}
+	err      error
+}
+
+// NewHandle creates a Handle that is not started yet.
+func NewHandle() *Handle {
+	return &Handle{
+		stop:    make(chan struct{}),
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start repeats ops in a separate goroutine the same way Repeat does.
+// It must be called only once.
+func (h *Handle) Start(ops ...Operation) *Handle {
+	go func() {
+		defer close(h.done)
+		h.err = WrapOnce(h.gate).Repeat(ops...)
+	}()
+
+	return h
+}
+
+// Stop prevents new attempts and waits for the current one to finish.
+// It returns the same as Wait.
+func (h *Handle) Stop() error {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+
+	return h.Wait()
+}
+
+// Pause prevents new attempts until Resume is called. The current
+// attempt and delay are not interrupted.
+func (h *Handle) Pause() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.paused {
+		h.paused = true
+		h.resume = make(chan struct{})
+	}
+}
+
+// Resume allows new attempts after Pause.
+func (h *Handle) Resume() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.paused {
+		h.paused = false
+		close(h.resume)
+	}
+}
+
+// TriggerNow skips the current delay or, if there is no one, the next
+// one.
+func (h *Handle) TriggerNow() {
+	select {
+	case h.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Done returns a channel that is closed when the repetition is finished.
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait waits for the repetition to finish and returns its final cause.
+// It returns nil if the repetition is stopped by Stop.
+func (h *Handle) Wait() error {
+	<-h.done
+
+	return h.err
+}
+
+// gate is a copw that waits while the handle is paused and stops the
+// repetition after Stop.
+func (h *Handle) gate(op Operation) Operation {
+	return func(e error) error {
+		for {
+			h.mu.Lock()
+			paused, resume := h.paused, h.resume
+			h.mu.Unlock()
+
+			select {
+			case <-h.stop:
+				return HintStop(nil)
+			default:
+			}
+			if !paused {
+				return op(e)
+			}
+
+			select {
+			case <-resume:
+			case <-h.stop:
+			}
+		}
+	}
+}
+
+// stopped returns a channel that is closed on Stop. It is safe to call
+// on nil Handle.
+func (h *Handle) stopped() <-chan struct{} {
+	if h == nil {
+		return nil
+	}
+
+	return h.stop
+}
+
+// triggered returns a channel that receives on TriggerNow. It is safe
+// to call on nil Handle.
+func (h *Handle) triggered() <-chan struct{} {
+	if h == nil {
+		return nil
+	}
+
+	return h.trigger
+}
@@ -0,0 +1,126 @@
+package repeat_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+// countOp returns an Operation that sends to calls on each call.
+func countOp(calls chan<- struct{}) repeat.Operation {
+	return func(e error) error {
+		calls <- struct{}{}
+		return nil
+	}
+}
+
+func startWithHandle(c repeat.Clock, ops ...repeat.Operation) *repeat.Handle {
+	h := repeat.NewHandle()
+	ops = append(ops, repeat.WithDelay(
+		repeat.FixedBackoff(time.Hour).Set(),
+		repeat.SetHandle(h),
+		repeat.SetClock(c),
+	))
+
+	return h.Start(ops...)
+}
+
+func TestHandle_StopInterruptsDelay(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	calls := make(chan struct{}, 10)
+	h := startWithHandle(c, countOp(calls))
+
+	<-calls
+	c.BlockUntil(2)
+	require.NoError(t, h.Stop())
+	require.Len(t, calls, 0)
+	require.Equal(t, 0, c.Timers(), "all timers should be stopped")
+
+	// Stop is idempotent.
+	require.NoError(t, h.Stop())
+}
+
+func TestHandle_StopWaitsAttempt(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	var finished int32
+	h := startWithHandle(c, func(e error) error {
+		<-c.After(time.Second)
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	})
+
+	// Let the attempt start and finish it concurrently with Stop.
+	c.BlockUntil(1)
+	go c.Advance(time.Second)
+	require.NoError(t, h.Stop())
+	require.EqualValues(t, 1, atomic.LoadInt32(&finished), "stop should wait for the current attempt")
+}
+
+func TestHandle_TriggerNow(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	calls := make(chan struct{}, 10)
+	h := startWithHandle(c, countOp(calls))
+
+	<-calls
+	c.BlockUntil(2)
+	h.TriggerNow()
+	<-calls
+	require.Equal(t, epoch, c.Now(), "no time should pass")
+
+	require.NoError(t, h.Stop())
+}
+
+func TestHandle_PauseResume(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	calls := make(chan struct{}, 10)
+	h := startWithHandle(c, countOp(calls))
+
+	<-calls
+	c.BlockUntil(2)
+	h.Pause()
+	h.Pause()
+	c.Advance(time.Hour)
+
+	// The delay is over, but the next attempt waits for Resume.
+	time.Sleep(time.Millisecond * 10)
+	require.Len(t, calls, 0, "no attempts should be made while paused")
+
+	h.Resume()
+	h.Resume()
+	<-calls
+
+	require.NoError(t, h.Stop())
+}
+
+func TestHandle_PauseStop(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	calls := make(chan struct{}, 10)
+	h := startWithHandle(c, countOp(calls))
+
+	<-calls
+	c.BlockUntil(2)
+	h.Pause()
+	c.Advance(time.Hour)
+
+	// Stop does not wait for Resume.
+	require.NoError(t, h.Stop())
+	require.Len(t, calls, 0, "no attempts should be made while paused")
+}
+
+func TestHandle_Wait(t *testing.T) {
+	h := repeat.NewHandle().Start(func(e error) error {
+		return errPeanut
+	})
+	<-h.Done()
+	require.Equal(t, errPeanut, h.Wait())
+
+	h = repeat.NewHandle().Start(repeat.FnHintStop(func(e error) error {
+		return nil
+	}))
+	require.NoError(t, h.Wait())
+}
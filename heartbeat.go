@@ -0,0 +1,208 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// HeartbeatStatus is a liveness status of Heartbeat.
+type HeartbeatStatus int
+
+const (
+	// HeartbeatStarting means the operation was not finished yet.
+	HeartbeatStarting HeartbeatStatus = iota
+
+	// HeartbeatHealthy means the last call of the operation succeeded.
+	HeartbeatHealthy
+
+	// HeartbeatDegraded means the operation fails with temporary errors
+	// but the heartbeat is still running.
+	HeartbeatDegraded
+
+	// HeartbeatFailed means the heartbeat is finished with an error.
+	HeartbeatFailed
+
+	// HeartbeatStopped means the heartbeat is stopped by Stop, by the
+	// context or by the operation itself.
+	HeartbeatStopped
+)
+
+func (s HeartbeatStatus) String() string {
+	switch s {
+	case HeartbeatStarting:
+		return "starting"
+	case HeartbeatHealthy:
+		return "healthy"
+	case HeartbeatDegraded:
+		return "degraded"
+	case HeartbeatFailed:
+		return "failed"
+	case HeartbeatStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// HeartbeatState is a snapshot of Heartbeat's state.
+type HeartbeatState struct {
+	// Status is the current liveness status.
+	Status HeartbeatStatus
+
+	// Since is the time the status was set at.
+	Since time.Time
+
+	// LastError is the cause of the last failed call of the operation
+	// or the error the heartbeat is finished with.
+	LastError error
+
+	// LastSuccess is the time of the last successful call of the
+	// operation. Zero value means there was no one.
+	LastSuccess time.Time
+}
+
+// Heartbeat calls an operation on a backoff schedule in a separate
+// goroutine and tracks its liveness.
+//
+// Heartbeat is safe for concurrent use.
+type Heartbeat struct {
+	mu      sync.Mutex
+	state   HeartbeatState
+	changes chan HeartbeatState
+
+	clock   Clock
+	cancel  context.CancelFunc
+	stopped bool
+	done    chan struct{}
+	err     error
+}
+
+// StartHeartbeat starts calling op with delays specified by the
+// WithDelay' options until ctx is done, Stop is called, op returns
+// StopError or any other non-temporary error or the errors timeout
+// expires.
+//
+// The context passed to op is canceled when the heartbeat is stopped.
+func StartHeartbeat(ctx context.Context, op ContextOperation, options ...func(*DelayOptions)) *Heartbeat {
+	ctx, cancel := context.WithCancel(ctx)
+	do := applyOptions(applyOptions(&DelayOptions{}, defaultOptions()), options)
+
+	h := &Heartbeat{
+		changes: make(chan HeartbeatState, 1),
+		clock:   do.Clock,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	h.state.Since = h.clock.Now()
+
+	// Do not modify the caller's slice.
+	options = append(options[:len(options):len(options)], SetContext(ctx), SetContextHintStop())
+	go h.run(ctx, op, options)
+
+	return h
+}
+
+func (h *Heartbeat) run(ctx context.Context, op ContextOperation, options []func(*DelayOptions)) {
+	defer close(h.done)
+
+	err := Repeat(
+		func(e error) error {
+			err := op(ctx, e)
+			h.report(err)
+
+			return err
+		},
+		WithDelay(options...),
+	)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer close(h.changes)
+
+	if h.stopped || ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		// The op is interrupted by Stop or by the context.
+		err = nil
+	}
+
+	h.err = err
+	if err != nil {
+		h.state.LastError = err
+		h.setStatus(HeartbeatFailed)
+	} else {
+		h.setStatus(HeartbeatStopped)
+	}
+}
+
+// report updates the state using the result of op.
+func (h *Heartbeat) report(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case err == nil:
+		h.state.LastSuccess = h.clock.Now()
+		h.setStatus(HeartbeatHealthy)
+	case IsTemporary(err):
+		h.state.LastError = Cause(err)
+		h.setStatus(HeartbeatDegraded)
+	}
+}
+
+// setStatus changes the status and notifies about the new state if
+// the status is changed.
+func (h *Heartbeat) setStatus(status HeartbeatStatus) {
+	if h.state.Status == status {
+		return
+	}
+	h.state.Status = status
+	h.state.Since = h.clock.Now()
+
+	// Replace the pending state with the latest one.
+	select {
+	case <-h.changes:
+	default:
+	}
+	h.changes <- h.state
+}
+
+// State returns the current state of the heartbeat.
+func (h *Heartbeat) State() HeartbeatState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.state
+}
+
+// Changes returns a channel that receives the state each time the
+// status changes. Only the latest state is kept if nobody reads the
+// channel. The channel is closed when the heartbeat is finished.
+func (h *Heartbeat) Changes() <-chan HeartbeatState {
+	return h.changes
+}
+
+// Done returns a channel that is closed when the heartbeat is finished.
+func (h *Heartbeat) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait waits for the heartbeat to finish and returns the error it is
+// finished with. It returns nil if the heartbeat is stopped by Stop or
+// if it is finished with the error of the done context.
+func (h *Heartbeat) Wait() error {
+	<-h.done
+
+	return h.err
+}
+
+// Stop stops the heartbeat and waits for it to finish.
+func (h *Heartbeat) Stop() error {
+	h.mu.Lock()
+	h.stopped = true
+	h.mu.Unlock()
+
+	h.cancel()
+
+	return h.Wait()
+}
@@ -0,0 +1,192 @@
+package repeat_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+func TestHeartbeat(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	results := make(chan error)
+	op := func(ctx context.Context, e error) error {
+		select {
+		case err := <-results:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	h := repeat.StartHeartbeat(context.Background(), op,
+		repeat.FixedBackoff(time.Second).Set(),
+		repeat.SetClock(c),
+	)
+	require.Equal(t, repeat.HeartbeatStarting, h.State().Status)
+
+	results <- nil
+	s := <-h.Changes()
+	require.Equal(t, repeat.HeartbeatHealthy, s.Status)
+	require.Equal(t, epoch, s.Since)
+	require.Equal(t, epoch, s.LastSuccess)
+	require.NoError(t, s.LastError)
+
+	c.BlockUntil(2)
+	c.Advance(time.Second)
+	results <- repeat.HintTemporary(errPeanut)
+	s = <-h.Changes()
+	require.Equal(t, repeat.HeartbeatDegraded, s.Status)
+	require.Equal(t, epoch.Add(time.Second), s.Since)
+	require.Equal(t, epoch, s.LastSuccess)
+	require.Equal(t, errPeanut, s.LastError)
+
+	// Degraded since the first failure without a change notification.
+	c.BlockUntil(2)
+	c.Advance(time.Second)
+	errSoap := errors.New("soap")
+	results <- repeat.HintTemporary(errSoap)
+	c.BlockUntil(2)
+	s = h.State()
+	require.Equal(t, repeat.HeartbeatDegraded, s.Status)
+	require.Equal(t, epoch.Add(time.Second), s.Since)
+	require.Equal(t, errSoap, s.LastError)
+	require.Len(t, h.Changes(), 0)
+
+	c.Advance(time.Second)
+	results <- nil
+	s = <-h.Changes()
+	require.Equal(t, repeat.HeartbeatHealthy, s.Status)
+	require.Equal(t, epoch.Add(3*time.Second), s.LastSuccess)
+
+	require.NoError(t, h.Stop())
+	require.Equal(t, repeat.HeartbeatStopped, h.State().Status)
+	require.Equal(t, repeat.HeartbeatStopped, (<-h.Changes()).Status)
+	_, ok := <-h.Changes()
+	require.False(t, ok, "changes should be closed")
+}
+
+func TestHeartbeat_ErrorsTimeout(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	op := func(context.Context, error) error {
+		return repeat.HintTemporary(errPeanut)
+	}
+
+	h := repeat.StartHeartbeat(context.Background(), op,
+		repeat.FixedBackoff(time.Second).Set(),
+		repeat.SetErrorsTimeout(1500*time.Millisecond),
+		repeat.SetClock(c),
+	)
+
+	c.BlockUntil(2)
+	c.Advance(time.Second)
+	c.BlockUntil(2)
+	c.Advance(500 * time.Millisecond)
+
+	require.Equal(t, errPeanut, h.Wait())
+	s := h.State()
+	require.Equal(t, repeat.HeartbeatFailed, s.Status)
+	require.Equal(t, epoch.Add(1500*time.Millisecond), s.Since)
+	require.Equal(t, errPeanut, s.LastError)
+	require.True(t, s.LastSuccess.IsZero())
+}
+
+func TestHeartbeat_Fatal(t *testing.T) {
+	op := func(context.Context, error) error {
+		return errPeanut
+	}
+
+	h := repeat.StartHeartbeat(context.Background(), op)
+	require.Equal(t, errPeanut, h.Wait())
+	require.Equal(t, repeat.HeartbeatFailed, h.State().Status)
+	require.Equal(t, errPeanut, h.Stop(), "stop after finish should return the same error")
+}
+
+func TestHeartbeat_ContextCanceled(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	ctx, cancel := context.WithCancel(context.Background())
+	op := func(context.Context, error) error {
+		return nil
+	}
+
+	h := repeat.StartHeartbeat(ctx, op, repeat.SetClock(c))
+	c.BlockUntil(2)
+	cancel()
+
+	<-h.Done()
+	require.NoError(t, h.Wait())
+	require.Equal(t, repeat.HeartbeatStopped, h.State().Status)
+}
+
+func TestHeartbeat_ContextCanceledFailure(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err    error
+		want   error
+		status repeat.HeartbeatStatus
+	}{
+		"failure":       {errPeanut, errPeanut, repeat.HeartbeatFailed},
+		"context error": {context.Canceled, nil, repeat.HeartbeatStopped},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			started := make(chan struct{})
+			op := func(ctx context.Context, _ error) error {
+				close(started)
+				<-ctx.Done()
+				return tc.err
+			}
+
+			h := repeat.StartHeartbeat(ctx, op)
+			<-started
+			cancel()
+
+			require.Equal(t, tc.want, h.Wait())
+			require.Equal(t, tc.status, h.State().Status)
+		})
+	}
+}
+
+func TestHeartbeat_StopFailure(t *testing.T) {
+	started := make(chan struct{})
+	op := func(ctx context.Context, _ error) error {
+		close(started)
+		<-ctx.Done()
+		return errPeanut
+	}
+
+	h := repeat.StartHeartbeat(context.Background(), op)
+	<-started
+	require.NoError(t, h.Stop())
+	require.Equal(t, repeat.HeartbeatStopped, h.State().Status)
+}
+
+func TestHeartbeat_OptionsNotModified(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	clock := repeat.SetClock(c)
+	options := make([]func(*repeat.DelayOptions), 1, 3)
+	options[0] = clock
+	spare := options[:3]
+	op := func(context.Context, error) error {
+		return nil
+	}
+
+	h := repeat.StartHeartbeat(context.Background(), op, options...)
+	c.BlockUntil(2)
+	require.NoError(t, h.Stop())
+	require.Nil(t, spare[1])
+	require.Nil(t, spare[2])
+}
+
+func TestHeartbeatStatus_String(t *testing.T) {
+	require.Equal(t, "starting", repeat.HeartbeatStarting.String())
+	require.Equal(t, "healthy", repeat.HeartbeatHealthy.String())
+	require.Equal(t, "degraded", repeat.HeartbeatDegraded.String())
+	require.Equal(t, "failed", repeat.HeartbeatFailed.String())
+	require.Equal(t, "stopped", repeat.HeartbeatStopped.String())
+	require.Equal(t, "unknown", repeat.HeartbeatStatus(-1).String())
+}
@@ -0,0 +1,136 @@
+package repeat
+
+import (
+	"context"
+	"time"
+)
+
+// SetHedgeMaxAttempts specifies the total number of attempts that can
+// be started. Attempts that fail with TemporaryError are not restarted,
+// so this is also the maximum number of attempts in flight. Values less
+// than 1 mean 1, op is always called at least once.
+//
+// Default value is 2.
+func SetHedgeMaxAttempts(n int) func(*HedgeOptions) {
+	return func(o *HedgeOptions) {
+		o.MaxAttempts = n
+	}
+}
+
+// SetHedgeBackoff specifies delays between starts of attempts using
+// one of the backoff builders.
+//
+// Default value is FixedBackoff(100 * time.Millisecond).
+func SetHedgeBackoff(b BackoffBuilder) func(*HedgeOptions) {
+	return func(o *HedgeOptions) {
+		o.Backoff = BuildBackoff(b)
+	}
+}
+
+// SetHedgeClock allows to set a clock instead of the system one.
+func SetHedgeClock(c Clock) func(*HedgeOptions) {
+	return func(o *HedgeOptions) {
+		o.Clock = c
+	}
+}
+
+// HedgeOptions holds parameters for hedged attempts.
+type HedgeOptions struct {
+	MaxAttempts int
+	Backoff     Backoff
+	Clock       Clock
+}
+
+// Hedge calls op and, if it has not finished within a hedge delay,
+// starts another speculative attempt in parallel, up to MaxAttempts
+// attempts in total. It returns as soon as any attempt succeeds and
+// cancels the contexts of the rest.
+//
+// It is guaranteed that op will be called at least once.
+func Hedge(ctx context.Context, op ContextOperation, options ...func(*HedgeOptions)) error {
+	return Cause(FnHedge(ctx, op, options...)(nil))
+}
+
+// FnHedge is a Hedge operation.
+//
+// Attempts that fail with TemporaryError let the next attempt start
+// right away. The operation returns when:
+//   - an attempt returns nil or StopError without a cause: nil;
+//   - an attempt returns StopError with a cause or any other error;
+//   - all attempts fail with TemporaryError: the last one;
+//   - ctx is done: the context error.
+//
+// The operation does not wait for canceled attempts to finish.
+func FnHedge(ctx context.Context, op ContextOperation, options ...func(*HedgeOptions)) Operation {
+	return func(e error) error {
+		o := HedgeOptions{MaxAttempts: 2, Clock: SystemClock}
+		SetHedgeBackoff(FixedBackoff(100 * time.Millisecond))(&o)
+		for _, option := range options {
+			option(&o)
+		}
+		if o.MaxAttempts < 1 {
+			o.MaxAttempts = 1
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan error, o.MaxAttempts)
+		launched, inFlight := 0, 0
+
+		var hedgeT Timer
+		defer func() {
+			if hedgeT != nil {
+				hedgeT.Stop()
+			}
+		}()
+
+		// launch starts the next attempt and schedules the next hedge.
+		launch := func() <-chan time.Time {
+			launched++
+			inFlight++
+			go func() {
+				results <- op(ctx, e)
+			}()
+
+			if hedgeT != nil {
+				hedgeT.Stop()
+				hedgeT = nil
+			}
+			if launched >= o.MaxAttempts {
+				return nil
+			}
+			hedgeT = o.Clock.NewTimer(o.Backoff.Next())
+
+			return hedgeT.C()
+		}
+
+		hedgeC := launch()
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case <-hedgeC:
+				hedgeC = launch()
+
+			case err := <-results:
+				inFlight--
+				switch {
+				case err == nil:
+					return nil
+				case IsTemporary(err):
+					if launched < o.MaxAttempts {
+						hedgeC = launch()
+					} else if inFlight == 0 {
+						return err
+					}
+				case IsStop(err) && Cause(err) == nil:
+					return nil
+				default:
+					return err
+				}
+			}
+		}
+	}
+}
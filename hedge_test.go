@@ -0,0 +1,185 @@
+package repeat_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+// hedgeOps is a ContextOperation which attempts return errors sent to
+// the corresponding channels.
+type hedgeOps struct {
+	mu       sync.Mutex
+	started  chan int
+	results  []chan error
+	canceled chan int
+}
+
+func newHedgeOps(n int) *hedgeOps {
+	h := &hedgeOps{started: make(chan int, n), canceled: make(chan int, n)}
+	for i := 0; i < n; i++ {
+		h.results = append(h.results, make(chan error, 1))
+	}
+
+	return h
+}
+
+func (h *hedgeOps) op() repeat.ContextOperation {
+	attempt := 0
+	return func(ctx context.Context, e error) error {
+		h.mu.Lock()
+		i := attempt
+		attempt++
+		h.mu.Unlock()
+
+		h.started <- i
+		select {
+		case err := <-h.results[i]:
+			return err
+		case <-ctx.Done():
+			h.canceled <- i
+			return ctx.Err()
+		}
+	}
+}
+
+func goHedge(ctx context.Context, op repeat.ContextOperation, options ...func(*repeat.HedgeOptions)) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- repeat.Hedge(ctx, op, options...)
+	}()
+
+	return ch
+}
+
+func TestHedge_FirstSuccessWins(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	h := newHedgeOps(2)
+
+	res := goHedge(context.Background(), h.op(),
+		repeat.SetHedgeMaxAttempts(2),
+		repeat.SetHedgeBackoff(repeat.FixedBackoff(time.Second)),
+		repeat.SetHedgeClock(c),
+	)
+	require.Equal(t, 0, <-h.started)
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	require.Equal(t, 1, <-h.started)
+	require.Equal(t, 0, c.Timers(), "no more hedges are allowed")
+
+	h.results[1] <- nil
+	require.NoError(t, <-res)
+	require.Equal(t, 0, <-h.canceled)
+}
+
+func TestHedge_FastAttempt(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	h := newHedgeOps(1)
+	h.results[0] <- nil
+
+	err := repeat.Hedge(context.Background(), h.op(), repeat.SetHedgeClock(c))
+	require.NoError(t, err)
+	require.Len(t, h.started, 1)
+	require.Equal(t, 0, c.Timers(), "hedge timer should be stopped")
+}
+
+func TestHedge_TemporaryStartsNext(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	h := newHedgeOps(3)
+
+	res := goHedge(context.Background(), h.op(),
+		repeat.SetHedgeMaxAttempts(3),
+		repeat.SetHedgeClock(c),
+	)
+	require.Equal(t, 0, <-h.started)
+
+	// No need to wait the hedge delay.
+	h.results[0] <- repeat.HintTemporary(errPeanut)
+	require.Equal(t, 1, <-h.started)
+	h.results[1] <- repeat.HintTemporary(errPeanut)
+	require.Equal(t, 2, <-h.started)
+
+	h.results[2] <- repeat.HintStop(nil)
+	require.NoError(t, <-res)
+}
+
+func TestHedge_AllTemporary(t *testing.T) {
+	h := newHedgeOps(2)
+	errLast := errors.New("last")
+	h.results[0] <- repeat.HintTemporary(errPeanut)
+	h.results[1] <- repeat.HintTemporary(errLast)
+
+	err := repeat.Hedge(context.Background(), h.op(), repeat.SetHedgeMaxAttempts(2))
+	require.Equal(t, errLast, err)
+}
+
+func TestHedge_Stop(t *testing.T) {
+	for name, e := range map[string]error{
+		"stop":  repeat.HintStop(errPeanut),
+		"fatal": errPeanut,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := repeattest.NewFakeClock(epoch)
+			h := newHedgeOps(2)
+
+			res := goHedge(context.Background(), h.op(), repeat.SetHedgeClock(c))
+			require.Equal(t, 0, <-h.started)
+			c.BlockUntil(1)
+			c.Advance(time.Second)
+			require.Equal(t, 1, <-h.started)
+
+			h.results[1] <- e
+			require.Equal(t, errPeanut, <-res)
+			require.Equal(t, 0, <-h.canceled)
+		})
+	}
+}
+
+func TestHedge_ContextCanceled(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	h := newHedgeOps(1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	res := goHedge(ctx, h.op(), repeat.SetHedgeClock(c))
+	require.Equal(t, 0, <-h.started)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-res)
+	require.Equal(t, 0, <-h.canceled)
+}
+
+func TestFnHedge_Repeat(t *testing.T) {
+	h := newHedgeOps(4)
+	h.results[0] <- repeat.HintTemporary(errPeanut)
+	h.results[1] <- repeat.HintTemporary(errPeanut)
+	h.results[2] <- nil
+
+	err := repeat.Repeat(
+		repeat.FnHedge(context.Background(), h.op(), repeat.SetHedgeMaxAttempts(2)),
+		repeat.StopOnSuccess(),
+	)
+	require.NoError(t, err)
+	require.Len(t, h.started, 3)
+}
+
+func TestHedge_ZeroMaxAttempts(t *testing.T) {
+	for _, option := range []func(*repeat.HedgeOptions){
+		repeat.SetHedgeMaxAttempts(0),
+		repeat.SetHedgeMaxAttempts(-1),
+		func(o *repeat.HedgeOptions) { o.MaxAttempts = 0 },
+	} {
+		h := newHedgeOps(1)
+		h.results[0] <- repeat.HintTemporary(errPeanut)
+
+		require.Equal(t, errPeanut, repeat.Hedge(context.Background(), h.op(), option))
+		require.Len(t, h.started, 1)
+	}
+}
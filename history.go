@@ -0,0 +1,150 @@
+package repeat
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Attempt describes a single call of an operation.
+type Attempt struct {
+	// Start is the time the operation was called at.
+	Start time.Time
+
+	// Duration is the time the operation took.
+	Duration time.Duration
+
+	// Err is the error returned by the operation.
+	Err error
+}
+
+// Recorder records every call of wrapped operations as an Attempt.
+//
+// Recorder is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	clock    Clock
+	attempts []Attempt
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{clock: SystemClock}
+}
+
+// WithClock allows to set a clock instead of the system one.
+func (r *Recorder) WithClock(c Clock) *Recorder {
+	r.clock = c
+	return r
+}
+
+// Wrap is an OpWrapper that records each call of op as an Attempt.
+func (r *Recorder) Wrap(op Operation) Operation {
+	return func(e error) error {
+		start := r.clock.Now()
+		err := op(e)
+		duration := r.clock.Now().Sub(start)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.attempts = append(r.attempts, Attempt{Start: start, Duration: duration, Err: err})
+
+		return err
+	}
+}
+
+// Attempts returns all recorded attempts.
+func (r *Recorder) Attempts() []Attempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Attempt(nil), r.attempts...)
+}
+
+// Reset forgets all recorded attempts.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = nil
+}
+
+// Err returns AttemptsError that holds the passed error and all
+// recorded attempts. It returns nil if the passed error is nil.
+func (r *Recorder) Err(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &AttemptsError{Err: err, Attempts: r.Attempts()}
+}
+
+// AttemptsError is returned by a Repeater created with WithHistory. It
+// holds errors of all attempts, not only the last cause.
+type AttemptsError struct {
+	// Err is the error the repetition is finished with.
+	Err error
+
+	// Attempts holds all attempts of the repetition.
+	Attempts []Attempt
+}
+
+func (e *AttemptsError) Error() string {
+	return fmt.Sprintf("%v (attempts: %d)", e.Err, len(e.Attempts))
+}
+
+// Unwrap returns the final error followed by causes of all failed
+// attempts. It allows errors.Is and errors.As to check each of them.
+func (e *AttemptsError) Unwrap() []error {
+	errs := []error{e.Err}
+	for _, a := range e.Attempts {
+		if Cause(a.Err) != nil {
+			errs = append(errs, Cause(a.Err))
+		}
+	}
+
+	return errs
+}
+
+// WithHistory returns object that records every call of the first op
+// passed to Once and Repeat using rec. Once and Repeat return
+// AttemptsError instead of the last cause in case of failure.
+//
+// Recorded attempts are reset at the beginning of each Once and Repeat.
+func WithHistory(r Repeater, rec *Recorder) Repeater {
+	return &historyRepeater{r, rec}
+}
+
+type historyRepeater struct {
+	r   Repeater
+	rec *Recorder
+}
+
+func (w *historyRepeater) Once(ops ...Operation) error {
+	w.rec.Reset()
+
+	return w.rec.Err(w.r.Once(w.wrap(ops)...))
+}
+
+func (w *historyRepeater) Repeat(ops ...Operation) error {
+	w.rec.Reset()
+
+	return w.rec.Err(w.r.Repeat(w.wrap(ops)...))
+}
+
+func (w *historyRepeater) Compose(ops ...Operation) Operation {
+	return w.r.Compose(w.wrap(ops)...)
+}
+
+func (w *historyRepeater) FnRepeat(ops ...Operation) Operation {
+	return w.r.FnRepeat(w.wrap(ops)...)
+}
+
+// wrap records the first op of ops.
+func (w *historyRepeater) wrap(ops []Operation) []Operation {
+	if len(ops) == 0 {
+		return ops
+	}
+
+	return append([]Operation{w.rec.Wrap(ops[0])}, ops[1:]...)
+}
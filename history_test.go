@@ -0,0 +1,115 @@
+package repeat
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tickClock is a Clock that moves forward by a second each time Now
+// is called.
+type tickClock struct {
+	Clock
+	now time.Time
+}
+
+func (c *tickClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func TestRecorder_Wrap(t *testing.T) {
+	c := &tickClock{}
+	rec := NewRecorder().WithClock(c)
+	op := rec.Wrap(func(e error) error {
+		return e
+	})
+
+	require.NoError(t, op(nil))
+	require.Equal(t, errGolden, op(errGolden))
+	require.Equal(t, []Attempt{
+		{Start: time.Time{}.Add(time.Second), Duration: time.Second},
+		{Start: time.Time{}.Add(3 * time.Second), Duration: time.Second, Err: errGolden},
+	}, rec.Attempts())
+
+	rec.Reset()
+	require.Empty(t, rec.Attempts())
+}
+
+func TestRecorder_ErrNil(t *testing.T) {
+	require.NoError(t, NewRecorder().Err(nil))
+}
+
+func TestWithHistory_Repeat(t *testing.T) {
+	errs := []error{errors.New("one"), errors.New("two"), errors.New("three")}
+	rec := NewRecorder()
+	err := WithHistory(NewRepeater(), rec).Repeat(
+		FnWithCounter(func(c int) error {
+			return HintTemporary(errs[c])
+		}),
+		StopOnSuccess(),
+		LimitMaxTries(2),
+	)
+
+	var ae *AttemptsError
+	require.True(t, errors.As(err, &ae))
+	require.Equal(t, errs[2], ae.Err)
+	require.Len(t, ae.Attempts, 3)
+	require.EqualError(t, err, "three (attempts: 3)")
+	for i, e := range errs {
+		require.Equal(t, e, Cause(ae.Attempts[i].Err))
+		require.True(t, errors.Is(err, e))
+	}
+	require.False(t, errors.Is(err, errGolden))
+}
+
+func TestWithHistory_As(t *testing.T) {
+	err := WithHistory(NewRepeater(), NewRecorder()).Once(func(e error) error {
+		return fmt.Errorf("wrapped: %w", &pathError{"/tmp"})
+	})
+
+	var pe *pathError
+	require.True(t, errors.As(err, &pe))
+	require.Equal(t, "/tmp", pe.path)
+}
+
+func TestWithHistory_Success(t *testing.T) {
+	rec := NewRecorder()
+	r := WithHistory(NewRepeater(), rec)
+	require.NoError(t, r.Repeat(
+		FnWithCounter(func(c int) error {
+			if c < 2 {
+				return HintTemporary(errGolden)
+			}
+
+			return nil
+		}),
+		StopOnSuccess(),
+	))
+	require.Len(t, rec.Attempts(), 3)
+
+	// Attempts are reset on each run.
+	require.NoError(t, r.Once(Nope))
+	require.Len(t, rec.Attempts(), 1)
+}
+
+func TestWithHistory_ComposeAndFnRepeat(t *testing.T) {
+	rec := NewRecorder()
+	r := WithHistory(NewRepeater(), rec)
+	require.NoError(t, r.Compose(Nope, Nope)(nil))
+	require.Len(t, rec.Attempts(), 1)
+	require.NoError(t, r.FnRepeat(StopOnSuccess(), Nope)(nil))
+	require.Len(t, rec.Attempts(), 2)
+	require.NoError(t, r.Once())
+}
+
+type pathError struct {
+	path string
+}
+
+func (e *pathError) Error() string {
+	return "bad path: " + e.path
+}
@@ -0,0 +1,135 @@
+package repeat
+
+import (
+	"time"
+)
+
+// ErrorKind classifies errors returned by operations.
+type ErrorKind int
+
+const (
+	// KindSuccess means no error.
+	KindSuccess ErrorKind = iota
+
+	// KindTemporary means TemporaryError.
+	KindTemporary
+
+	// KindStop means StopError.
+	KindStop
+
+	// KindFatal means any other error. It stops the repetition.
+	KindFatal
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindSuccess:
+		return "success"
+	case KindTemporary:
+		return "temporary"
+	case KindStop:
+		return "stop"
+	case KindFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+// Classify returns ErrorKind of the passed error.
+func Classify(err error) ErrorKind {
+	switch {
+	case err == nil:
+		return KindSuccess
+	case IsTemporary(err):
+		return KindTemporary
+	case IsStop(err):
+		return KindStop
+	default:
+		return KindFatal
+	}
+}
+
+// Observer receives notifications about a repetition process. It
+// allows to plug logging and metrics in without wrapping each op.
+//
+// Attempts are calls of the first op passed to Once, Repeat,
+// Compose or FnRepeat. They are numbered from 0.
+type Observer interface {
+	// OnAttemptStart is called before an attempt.
+	OnAttemptStart(attempt int)
+
+	// OnAttemptEnd is called after an attempt with its error, the
+	// error's kind and the time the attempt took.
+	OnAttemptEnd(attempt int, kind ErrorKind, err error, elapsed time.Duration)
+
+	// OnDelay is called by WithDelay with the chosen delay and the
+	// error the delay is caused by.
+	OnDelay(delay time.Duration, e error)
+
+	// OnStop is called when Once or Repeat is finished with the final
+	// cause and the time the whole repetition took.
+	OnStop(cause error, elapsed time.Duration)
+}
+
+// NopObserver is an Observer that does nothing. Embed it to implement
+// only the necessary methods.
+type NopObserver struct{}
+
+// OnAttemptStart does nothing.
+func (NopObserver) OnAttemptStart(int) {}
+
+// OnAttemptEnd does nothing.
+func (NopObserver) OnAttemptEnd(int, ErrorKind, error, time.Duration) {}
+
+// OnDelay does nothing.
+func (NopObserver) OnDelay(time.Duration, error) {}
+
+// OnStop does nothing.
+func (NopObserver) OnStop(error, time.Duration) {}
+
+// MultiObserver returns an Observer that notifies all passed ones.
+func MultiObserver(obs ...Observer) Observer {
+	return multiObserver(obs)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) OnAttemptStart(attempt int) {
+	for _, o := range m {
+		o.OnAttemptStart(attempt)
+	}
+}
+
+func (m multiObserver) OnAttemptEnd(attempt int, kind ErrorKind, err error, elapsed time.Duration) {
+	for _, o := range m {
+		o.OnAttemptEnd(attempt, kind, err, elapsed)
+	}
+}
+
+func (m multiObserver) OnDelay(delay time.Duration, e error) {
+	for _, o := range m {
+		o.OnDelay(delay, e)
+	}
+}
+
+func (m multiObserver) OnStop(cause error, elapsed time.Duration) {
+	for _, o := range m {
+		o.OnStop(cause, elapsed)
+	}
+}
+
+// fnObserveAttempts reports each call of op as an attempt to o.
+func fnObserveAttempts(o Observer, c Clock, op Operation) Operation {
+	attempt := 0
+	return func(e error) error {
+		defer func() { attempt++ }()
+
+		o.OnAttemptStart(attempt)
+		start := c.Now()
+		err := op(e)
+		o.OnAttemptEnd(attempt, Classify(err), err, c.Now().Sub(start))
+
+		return err
+	}
+}
@@ -0,0 +1,135 @@
+package repeat
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// eventObserver records all notifications as strings.
+type eventObserver struct {
+	events []string
+}
+
+func (o *eventObserver) OnAttemptStart(attempt int) {
+	o.events = append(o.events, fmt.Sprintf("start %d", attempt))
+}
+
+func (o *eventObserver) OnAttemptEnd(attempt int, kind ErrorKind, err error, elapsed time.Duration) {
+	o.events = append(o.events, fmt.Sprintf("end %d %s %v", attempt, kind, err))
+}
+
+func (o *eventObserver) OnDelay(delay time.Duration, e error) {
+	o.events = append(o.events, fmt.Sprintf("delay %v %v", delay, e))
+}
+
+func (o *eventObserver) OnStop(cause error, elapsed time.Duration) {
+	o.events = append(o.events, fmt.Sprintf("stop %v", cause))
+}
+
+func TestClassify(t *testing.T) {
+	require.Equal(t, KindSuccess, Classify(nil))
+	require.Equal(t, KindTemporary, Classify(HintTemporary(errGolden)))
+	require.Equal(t, KindStop, Classify(HintStop(nil)))
+	require.Equal(t, KindStop, Classify(fmt.Errorf("wrapped: %w", HintStop(nil))))
+	require.Equal(t, KindFatal, Classify(errGolden))
+
+	require.Equal(t, "success", KindSuccess.String())
+	require.Equal(t, "temporary", KindTemporary.String())
+	require.Equal(t, "stop", KindStop.String())
+	require.Equal(t, "fatal", KindFatal.String())
+	require.Equal(t, "unknown", ErrorKind(42).String())
+}
+
+func TestObserve_Repeat(t *testing.T) {
+	o := &eventObserver{}
+	require.Equal(t, errGolden, Observe(o).Repeat(
+		FnWithCounter(func(c int) error {
+			switch c {
+			case 0:
+				return HintTemporary(errors.New("busy"))
+			case 1:
+				return nil
+			default:
+				return errGolden
+			}
+		}),
+		WithDelay(FixedBackoff(0).Set(), SetObserver(o)),
+	))
+	require.Equal(t, []string{
+		"start 0",
+		"end 0 temporary repeat.temporary: busy",
+		"delay 0s repeat.temporary: busy",
+		"start 1",
+		"end 1 success <nil>",
+		"delay 0s <nil>",
+		"start 2",
+		"end 2 fatal golden",
+		"stop golden",
+	}, o.events)
+}
+
+func TestObserve_Once(t *testing.T) {
+	o := &eventObserver{}
+	require.NoError(t, Observe(o).Once(Nope, Nope))
+	require.Equal(t, []string{"start 0", "end 0 success <nil>", "stop <nil>"}, o.events)
+}
+
+func TestNewObservedRepeater(t *testing.T) {
+	o := &eventObserver{}
+	c := 0
+	wr := func(op Operation) Operation {
+		c++
+		return op
+	}
+
+	require.NoError(t, NewObservedRepeater(wr, wr, o, SystemClock).Once(HintStop))
+	require.Equal(t, 2, c)
+	require.Equal(t, []string{"start 0", "end 0 stop repeat.stop", "stop <nil>"}, o.events)
+}
+
+// elapsedObserver records durations reported by OnAttemptEnd and
+// OnStop.
+type elapsedObserver struct {
+	NopObserver
+	attempts []time.Duration
+	stop     time.Duration
+}
+
+func (o *elapsedObserver) OnAttemptEnd(_ int, _ ErrorKind, _ error, elapsed time.Duration) {
+	o.attempts = append(o.attempts, elapsed)
+}
+
+func (o *elapsedObserver) OnStop(_ error, elapsed time.Duration) {
+	o.stop = elapsed
+}
+
+func TestNewObservedRepeater_Clock(t *testing.T) {
+	o := &elapsedObserver{}
+	r := NewObservedRepeater(Forward, Forward, o, &tickClock{})
+
+	require.NoError(t, r.Repeat(FnWithCounter(func(c int) error {
+		if c == 0 {
+			return HintTemporary(errGolden)
+		}
+		return HintStop(nil)
+	})))
+	require.Equal(t, []time.Duration{time.Second, time.Second}, o.attempts)
+	require.Equal(t, 5*time.Second, o.stop)
+}
+
+func TestMultiObserver(t *testing.T) {
+	o1, o2 := &eventObserver{}, &eventObserver{}
+	o := MultiObserver(o1, NopObserver{}, o2)
+	o.OnAttemptStart(1)
+	o.OnAttemptEnd(1, KindFatal, errGolden, time.Second)
+	o.OnDelay(time.Second, nil)
+	o.OnStop(errGolden, time.Minute)
+
+	expected := []string{"start 1", "end 1 fatal golden", "delay 1s <nil>", "stop golden"}
+	require.Equal(t, expected, o1.events)
+	require.Equal(t, expected, o2.events)
+}
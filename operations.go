@@ -1,8 +1,24 @@
 package repeat
 
+import (
+	"context"
+	"time"
+)
+
 // Operation is the type of function for repetition.
 type Operation func(error) error
 
+// ContextOperation is the type of function for repetition that accepts
+// a context.
+type ContextOperation func(context.Context, error) error
+
+// FnWithContext makes an Operation that calls op with the given context.
+func FnWithContext(ctx context.Context, op ContextOperation) Operation {
+	return func(e error) error {
+		return op(ctx, e)
+	}
+}
+
 // LimitMaxTries returns true if attempt number is less then max.
 func LimitMaxTries(max int) Operation {
 	return FnWithErrorAndCounter(func(e error, c int) error {
@@ -14,6 +30,35 @@ func LimitMaxTries(max int) Operation {
 	})
 }
 
+// LimitMaxAttempts limits the total number of calls of the operation
+// it follows, i.e. the first call and retries, to n. It is the same as
+// LimitMaxTries(n-1). Values less than 1 mean 1.
+func LimitMaxAttempts(n int) Operation {
+	if n < 1 {
+		n = 1
+	}
+
+	return LimitMaxTries(n - 1)
+}
+
+// LimitMaxElapsed stops the repetition with the last error when at
+// least d passed since the operation is created. It does not take the
+// next delay into account, see SetMaxElapsed for that.
+func LimitMaxElapsed(d time.Duration) Operation {
+	return limitMaxElapsed(d, SystemClock)
+}
+
+func limitMaxElapsed(d time.Duration, clock Clock) Operation {
+	start := clock.Now()
+	return func(e error) error {
+		if clock.Now().Sub(start) < d {
+			return e
+		}
+
+		return HintStop(e)
+	}
+}
+
 // StopOnSuccess returns true in case of error is nil.
 func StopOnSuccess() Operation {
 	return func(e error) error {
@@ -51,10 +96,10 @@ func FnOnError(op Operation) Operation {
 func FnHintTemporary(op Operation) Operation {
 	return func(e error) error {
 		err := op(e)
-		switch err.(type) {
-		case nil:
-		case *TemporaryError:
-		case *StopError:
+		switch {
+		case err == nil:
+		case IsTemporary(err):
+		case IsStop(err):
 		default:
 			err = HintTemporary(err)
 		}
@@ -67,9 +112,9 @@ func FnHintTemporary(op Operation) Operation {
 func FnHintStop(op Operation) Operation {
 	return func(e error) error {
 		err := op(e)
-		switch err.(type) {
-		case *TemporaryError:
-		case *StopError:
+		switch {
+		case IsTemporary(err):
+		case IsStop(err):
 		default:
 			err = HintStop(err)
 		}
@@ -83,10 +128,10 @@ func FnHintStop(op Operation) Operation {
 func FnPanic(op Operation) Operation {
 	return func(e error) error {
 		err := op(e)
-		switch err.(type) {
-		case nil:
-		case *TemporaryError:
-		case *StopError:
+		switch {
+		case err == nil:
+		case IsTemporary(err):
+		case IsStop(err):
 		default:
 			panic(err)
 		}
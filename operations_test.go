@@ -2,6 +2,7 @@ package repeat
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -75,6 +76,17 @@ func TestLimitMaxTries(t *testing.T) {
 	assert.False(t, fn(nil) == nil)
 }
 
+func TestLimitMaxAttempts(t *testing.T) {
+	for n, want := range map[int]int{3: 3, 1: 1, 0: 1, -1: 1} {
+		calls := 0
+		require.Equal(t, errGolden, Repeat(func(e error) error {
+			calls++
+			return HintTemporary(errGolden)
+		}, LimitMaxAttempts(n)), n)
+		require.Equal(t, want, calls, n)
+	}
+}
+
 func TestStopOnSuccess(t *testing.T) {
 	fn := StopOnSuccess()
 	assert.True(t, fn(fn(nil)) != nil)
@@ -205,3 +217,10 @@ func TestFnOnlyOnce(t *testing.T) {
 
 	require.Equal(t, 1, c)
 }
+
+func TestFnHintTemporary_WrappedHints(t *testing.T) {
+	stop := fmt.Errorf("wrapped: %w", HintStop(errors.New("kiwi")))
+	require.Equal(t, stop, FnHintTemporary(func(error) error { return stop })(nil))
+	require.Equal(t, stop, FnHintStop(func(error) error { return stop })(nil))
+	require.Equal(t, stop, FnPanic(func(error) error { return stop })(nil))
+}
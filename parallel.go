@@ -0,0 +1,124 @@
+package repeat
+
+import (
+	"errors"
+)
+
+// ErrNoQuorum is returned by FnQuorum when the quorum is greater than
+// the number of operations.
+var ErrNoQuorum = errors.New("repeat: quorum is greater than the number of operations")
+
+// FnAll makes an Operation that calls all ops concurrently with the same
+// input error and waits for all of them.
+//
+// It returns nil if all ops succeeded. Otherwise it returns the most
+// severe error: any other error wins over StopError that wins over
+// TemporaryError. The first one in ops order is returned among errors
+// of the same kind.
+func FnAll(ops ...Operation) Operation {
+	return func(e error) error {
+		errs := make([]error, len(ops))
+		done := make(chan struct{}, len(ops))
+		for i, op := range ops {
+			go func(i int, op Operation) {
+				errs[i] = op(e)
+				done <- struct{}{}
+			}(i, op)
+		}
+		for range ops {
+			<-done
+		}
+
+		return mostSevere(errs)
+	}
+}
+
+// FnFirstError makes an Operation that calls all ops concurrently with
+// the same input error and returns the first error returned by any of
+// them. It returns nil if all ops succeeded.
+//
+// The result is known as soon as the first error is returned, but the
+// operation waits for the rest of ops to finish. So ops can safely keep
+// state between calls, e.g. inside Repeat.
+func FnFirstError(ops ...Operation) Operation {
+	return func(e error) error {
+		results := runParallel(e, ops)
+		var first error
+		for range ops {
+			if err := <-results; first == nil {
+				first = err
+			}
+		}
+
+		return first
+	}
+}
+
+// FnQuorum makes an Operation that calls all ops concurrently with the
+// same input error and returns nil if at least k of them succeeded.
+//
+// As soon as the quorum becomes unreachable the result is the most
+// severe of errors returned so far, the same way FnAll does. So the
+// failed quorum is temporary only if all failures are temporary.
+//
+// The result is known as soon as the quorum is reached or becomes
+// unreachable, but the operation waits for the rest of ops to finish.
+// So ops can safely keep state between calls, e.g. inside Repeat.
+func FnQuorum(k int, ops ...Operation) Operation {
+	return func(e error) error {
+		switch {
+		case k <= 0:
+			return nil
+		case k > len(ops):
+			return ErrNoQuorum
+		}
+
+		results := runParallel(e, ops)
+		succeeded := 0
+		known := false
+		var errs []error
+		var r error
+		for range ops {
+			err := <-results
+			switch {
+			case known:
+				// Remaining ops do not change the result.
+			case err == nil:
+				succeeded++
+				known = succeeded >= k
+			default:
+				errs = append(errs, err)
+				if len(ops)-len(errs) < k {
+					r, known = mostSevere(errs), true
+				}
+			}
+		}
+
+		return r
+	}
+}
+
+// runParallel calls each op in a separate goroutine and returns a
+// channel with their results in completion order.
+func runParallel(e error, ops []Operation) <-chan error {
+	results := make(chan error, len(ops))
+	for _, op := range ops {
+		go func(op Operation) {
+			results <- op(e)
+		}(op)
+	}
+
+	return results
+}
+
+// mostSevere returns the first error of the most severe kind.
+func mostSevere(errs []error) error {
+	var r error
+	for _, err := range errs {
+		if Classify(err) > Classify(r) {
+			r = err
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,182 @@
+package repeat
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// errOp returns an Operation that returns err.
+func errOp(err error) Operation {
+	return func(error) error {
+		return err
+	}
+}
+
+// blockOp returns an Operation that returns err after release is closed.
+func blockOp(release <-chan struct{}, err error) Operation {
+	return func(error) error {
+		<-release
+		return err
+	}
+}
+
+func TestFnAll(t *testing.T) {
+	kiwi := errors.New("kiwi")
+	temporary := HintTemporary(kiwi)
+	stop := HintStop(kiwi)
+
+	require.NoError(t, FnAll()(nil))
+	require.NoError(t, FnAll(Nope, Nope, Nope)(nil))
+	require.Equal(t, temporary, FnAll(Nope, errOp(temporary))(nil))
+	require.Equal(t, stop, FnAll(errOp(temporary), errOp(stop), Nope)(nil))
+	require.Equal(t, errGolden, FnAll(errOp(stop), errOp(errGolden), errOp(temporary))(nil))
+
+	// The first error of the same kind wins.
+	require.Equal(t, errGolden, FnAll(errOp(errGolden), errOp(kiwi))(nil))
+}
+
+func TestFnAllWaitsAll(t *testing.T) {
+	var calls int32
+	op := func(e error) error {
+		atomic.AddInt32(&calls, 1)
+		return e
+	}
+
+	// All ops are called with the same input error.
+	require.Equal(t, errGolden, FnAll(op, op, op)(errGolden))
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestFnAllConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	op := FnAll(blockOp(release, nil), func(error) error {
+		close(release)
+		return nil
+	})
+
+	require.NoError(t, op(nil))
+}
+
+func TestFnAllInRepeat(t *testing.T) {
+	var calls int32
+	flaky := func(error) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return HintTemporary(errGolden)
+		}
+		return nil
+	}
+
+	require.NoError(t, Repeat(FnAll(flaky, errOp(nil)), StopOnSuccess()))
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestFnFirstError(t *testing.T) {
+	require.NoError(t, FnFirstError()(nil))
+	require.NoError(t, FnFirstError(Nope, Nope)(nil))
+	require.Equal(t, errGolden, FnFirstError(Nope, errOp(errGolden))(nil))
+}
+
+// waitsAll checks that op made of a blocked op and fast ops returns
+// only after the blocked op finished.
+func waitsAll(t *testing.T, op func(blocked Operation, fast ...Operation) Operation, fast ...Operation) error {
+	release := make(chan struct{})
+	var finished int32
+	blocked := func(error) error {
+		<-release
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	wrapped := make([]Operation, len(fast))
+	for i, f := range fast {
+		wg.Add(1)
+		wrapped[i] = func(f Operation) Operation {
+			return func(e error) error {
+				defer wg.Done()
+				return f(e)
+			}
+		}(f)
+	}
+
+	res := make(chan error, 1)
+	go func() {
+		res <- op(blocked, wrapped...)(nil)
+	}()
+
+	// The result is known once fast ops finished.
+	wg.Wait()
+	select {
+	case <-res:
+		t.Fatal("the operation returned before the blocked op finished")
+	default:
+	}
+
+	close(release)
+	err := <-res
+	require.EqualValues(t, 1, atomic.LoadInt32(&finished), "the operation returned before the blocked op finished")
+
+	return err
+}
+
+func TestFnFirstErrorWaitsAll(t *testing.T) {
+	err := waitsAll(t, func(blocked Operation, fast ...Operation) Operation {
+		return FnFirstError(append([]Operation{blocked}, fast...)...)
+	}, errOp(HintTemporary(errGolden)))
+	require.True(t, IsTemporary(err))
+	require.Equal(t, errGolden, Cause(err))
+}
+
+func TestFnQuorum(t *testing.T) {
+	kiwi := errors.New("kiwi")
+	temporary := HintTemporary(kiwi)
+
+	require.NoError(t, FnQuorum(0)(nil))
+	require.Equal(t, ErrNoQuorum, FnQuorum(2, Nope)(nil))
+	require.NoError(t, FnQuorum(2, Nope, errOp(temporary), Nope)(nil))
+	require.Equal(t, temporary, FnQuorum(2, Nope, errOp(temporary), errOp(temporary))(nil))
+	require.Equal(t, errGolden, FnQuorum(3, Nope, errOp(errGolden), Nope)(nil))
+}
+
+func TestFnQuorumWaitsAll(t *testing.T) {
+	quorum := func(blocked Operation, fast ...Operation) Operation {
+		return FnQuorum(2, append(fast, blocked)...)
+	}
+
+	// The quorum is reached.
+	require.NoError(t, waitsAll(t, quorum, Nope, Nope))
+
+	// The quorum is unreachable.
+	require.Equal(t, errGolden, waitsAll(t, quorum, errOp(errGolden), errOp(errGolden)))
+}
+
+// TestParallelInRepeat checks that ops with state do not outlive their
+// call. Run it with -race.
+func TestParallelInRepeat(t *testing.T) {
+	temporary := errOp(HintTemporary(errGolden))
+	for name, fn := range map[string]func(Operation) Operation{
+		"first-error": func(op Operation) Operation {
+			return FnFirstError(op, temporary)
+		},
+		"quorum": func(op Operation) Operation {
+			return FnQuorum(2, temporary, temporary, op)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			slow := FnWithCounter(func(int) error {
+				runtime.Gosched()
+				calls++
+				return nil
+			})
+
+			require.Equal(t, errGolden, Repeat(fn(slow), LimitMaxTries(19)))
+			require.Equal(t, 20, calls)
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package repeat
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backoffNames maps names of the compact syntax to backoff kinds.
+var backoffNames = map[string]BackoffKind{
+	"fixed":        BackoffFixed,
+	"fulljitter":   BackoffFullJitter,
+	"exp":          BackoffExponential,
+	"decorrelated": BackoffDecorrelatedJitter,
+	"equaljitter":  BackoffEqualJitter,
+}
+
+// ParseBackoff parses a backoff builder written in the compact syntax.
+// The first parameter can be passed without a name. Examples:
+//
+//	fixed(1s)
+//	fulljitter(500ms,max=10s)
+//	exp(initial=100ms,mult=2,max=30s,jitter=0.2)
+//	decorrelated(base=100ms,max=10s)
+//	equaljitter(100ms,max=10s)
+//
+// Omitted parameters have the builders' default values. The first one
+// is 1 second by default. Any values the builders accept are allowed,
+// so String of a builder is always parsed back to the same builder.
+func ParseBackoff(s string) (BackoffBuilder, error) {
+	spec, err := parseBackoffSpec(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return spec.builder(), nil
+}
+
+// ParsePolicy parses a policy written in the compact syntax: a backoff
+// in the ParseBackoff syntax and limits separated by semicolons. All
+// parts are optional. The backoff should be valid as BackoffPolicy, so
+// its delays and multiplier cannot be zero. Example:
+//
+//	exp(initial=100ms,max=30s);tries=10;timeout=1m
+//
+// Supported limits:
+//   - tries: Policy.MaxTries;
+//   - timeout: Policy.MaxElapsed;
+//   - errors_timeout: Policy.ErrorsTimeout;
+//   - reset: Policy.ResetOnSuccess;
+//   - retry_after: Policy.RetryAfter.
+func ParsePolicy(s string) (*Policy, error) {
+	p := &Policy{}
+	backoff := false
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+			continue
+		case strings.Contains(part, "("):
+			if backoff {
+				return nil, fmt.Errorf("repeat: policy %q has more than one backoff", s)
+			}
+			spec, err := parseBackoffSpec(part)
+			if err != nil {
+				return nil, err
+			}
+			bp, err := spec.policy()
+			if err != nil {
+				return nil, err
+			}
+			p.Backoff, backoff = *bp, true
+			continue
+		}
+
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("repeat: policy parameter %q has no value", part)
+		}
+
+		var err error
+		switch strings.TrimSpace(key) {
+		case "tries":
+			p.MaxTries, err = strconv.Atoi(strings.TrimSpace(value))
+		case "timeout":
+			err = p.MaxElapsed.UnmarshalText([]byte(strings.TrimSpace(value)))
+		case "errors_timeout":
+			err = p.ErrorsTimeout.UnmarshalText([]byte(strings.TrimSpace(value)))
+		case "reset":
+			p.ResetOnSuccess, err = strconv.ParseBool(strings.TrimSpace(value))
+		case "retry_after":
+			err = p.RetryAfter.UnmarshalText([]byte(strings.TrimSpace(value)))
+		default:
+			return nil, fmt.Errorf("repeat: unknown policy parameter %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("repeat: bad policy parameter %q: %w", part, err)
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// String returns the policy in the compact syntax accepted by
+// ParsePolicy.
+func (p *Policy) String() string {
+	if p == nil {
+		return ""
+	}
+
+	var parts []string
+	if p.Backoff != (BackoffPolicy{}) {
+		parts = append(parts, p.Backoff.String())
+	}
+	if p.MaxTries != 0 {
+		parts = append(parts, "tries="+strconv.Itoa(p.MaxTries))
+	}
+	if p.MaxElapsed != 0 {
+		parts = append(parts, "timeout="+p.MaxElapsed.String())
+	}
+	if p.ErrorsTimeout != 0 {
+		parts = append(parts, "errors_timeout="+p.ErrorsTimeout.String())
+	}
+	if p.ResetOnSuccess {
+		parts = append(parts, "reset=true")
+	}
+	if p.RetryAfter != RetryAfterOverride {
+		parts = append(parts, "retry_after="+p.RetryAfter.String())
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Set parses the policy in the compact syntax. It allows to use Policy
+// as flag.Value.
+func (p *Policy) Set(s string) error {
+	v, err := ParsePolicy(s)
+	if err != nil {
+		return err
+	}
+
+	*p = *v
+	return nil
+}
+
+// String returns the backoff policy in the compact syntax accepted by
+// ParseBackoff.
+func (p *BackoffPolicy) String() string {
+	return fmt.Sprint(p.Builder())
+}
+
+// backoffSpec is a backoff in the compact syntax. Omitted parameters
+// are nil.
+type backoffSpec struct {
+	kind   BackoffKind
+	base   *time.Duration
+	max    *time.Duration
+	mult   *float64
+	jitter *float64
+}
+
+// parseBackoffSpec parses a backoff in the compact syntax.
+func parseBackoffSpec(s string) (*backoffSpec, error) {
+	s = strings.TrimSpace(s)
+	open := strings.Index(s, "(")
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("repeat: bad backoff %q, expected name(parameters)", s)
+	}
+
+	name := strings.TrimSpace(s[:open])
+	kind, ok := backoffNames[name]
+	if !ok {
+		return nil, fmt.Errorf("repeat: unknown backoff %q", name)
+	}
+
+	// The first parameter can be passed without a name.
+	first := "base"
+	switch kind {
+	case BackoffFixed:
+		first = "delay"
+	case BackoffExponential:
+		first = "initial"
+	}
+
+	spec := &backoffSpec{kind: kind}
+	var args []string
+	if v := strings.TrimSpace(s[open+1 : len(s)-1]); v != "" {
+		args = strings.Split(v, ",")
+	}
+
+	for i, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			if i != 0 {
+				return nil, fmt.Errorf("repeat: backoff parameter %q has no name", arg)
+			}
+			key, value = first, arg
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		var err error
+		switch {
+		case key == first:
+			spec.base, err = parseDurationParam(value)
+		case key == "max" && kind != BackoffFixed:
+			spec.max, err = parseDurationParam(value)
+		case key == "mult" && kind == BackoffExponential:
+			spec.mult, err = parseFloatParam(value)
+		case key == "jitter" && kind == BackoffExponential:
+			spec.jitter, err = parseFloatParam(value)
+			if err == nil && (*spec.jitter < 0 || *spec.jitter > 1) {
+				err = errors.New("jitter should be in range [0..1]")
+			}
+		default:
+			return nil, fmt.Errorf("repeat: unknown parameter %q of %s backoff", key, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("repeat: bad backoff parameter %q: %w", arg, err)
+		}
+	}
+
+	return spec, nil
+}
+
+// builder returns a backoff builder configured by the spec.
+func (s *backoffSpec) builder() BackoffBuilder {
+	base := time.Second
+	if s.base != nil {
+		base = *s.base
+	}
+
+	switch s.kind {
+	case BackoffFullJitter:
+		b := FullJitterBackoff(base)
+		if s.max != nil {
+			b.WithMaxDelay(*s.max)
+		}
+		return b
+
+	case BackoffExponential:
+		b := ExponentialBackoff(base)
+		if s.max != nil {
+			b.WithMaxDelay(*s.max)
+		}
+		if s.mult != nil {
+			b.WithMultiplier(*s.mult)
+		}
+		if s.jitter != nil {
+			b.WithJitter(*s.jitter)
+		}
+		return b
+
+	case BackoffDecorrelatedJitter:
+		b := DecorrelatedJitterBackoff(base)
+		if s.max != nil {
+			b.WithMaxDelay(*s.max)
+		}
+		return b
+
+	case BackoffEqualJitter:
+		b := EqualJitterBackoff(base)
+		if s.max != nil {
+			b.WithMaxDelay(*s.max)
+		}
+		return b
+
+	default:
+		return FixedBackoff(base)
+	}
+}
+
+// policy returns a valid backoff policy configured by the spec. Zero
+// values of BackoffPolicy mean defaults, so zero delays and multiplier
+// cannot be set explicitly.
+func (s *backoffSpec) policy() (*BackoffPolicy, error) {
+	if s.base != nil && *s.base == 0 || s.max != nil && *s.max == 0 || s.mult != nil && *s.mult == 0 {
+		return nil, errors.New("repeat: zero backoff parameters are not supported by policy, omit them to use defaults")
+	}
+
+	p := &BackoffPolicy{Kind: s.kind}
+	if s.base != nil {
+		p.BaseDelay = Duration(*s.base)
+	}
+	if s.max != nil {
+		p.MaxDelay = Duration(*s.max)
+	}
+	if s.mult != nil {
+		p.Multiplier = *s.mult
+	}
+	if s.jitter != nil {
+		p.Jitter = *s.jitter
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func parseDurationParam(s string) (*time.Duration, error) {
+	var d Duration
+	if err := d.UnmarshalText([]byte(s)); err != nil {
+		return nil, err
+	}
+
+	v := time.Duration(d)
+	return &v, nil
+}
+
+func parseFloatParam(s string) (*float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// formatBackoff formats a backoff in the compact syntax.
+func formatBackoff(name string, first string, params ...string) string {
+	return name + "(" + strings.Join(append([]string{first}, params...), ",") + ")"
+}
+
+// maxDelayParam formats a max delay parameter if it differs from the
+// default one.
+func maxDelayParam(d time.Duration) []string {
+	if d == 1<<63-1 {
+		return nil
+	}
+
+	return []string{"max=" + d.String()}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,180 @@
+package repeat
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBackoff(t *testing.T) {
+	for s, expected := range map[string]BackoffBuilder{
+		"fixed(1s)":                  FixedBackoff(time.Second),
+		"fixed(delay=1s)":            FixedBackoff(time.Second),
+		"fixed()":                    FixedBackoff(time.Second),
+		"fulljitter(500ms,max=10s)":  FullJitterBackoff(500 * time.Millisecond).WithMaxDelay(10 * time.Second),
+		" fulljitter( base=500ms ) ": FullJitterBackoff(500 * time.Millisecond),
+		"exp(initial=100ms,mult=2,max=30s,jitter=0.2)": ExponentialBackoff(100 * time.Millisecond).WithMaxDelay(30 * time.Second).WithJitter(0.2),
+		"exp(100ms,mult=1.5)":                          ExponentialBackoff(100 * time.Millisecond).WithMultiplier(1.5),
+		"decorrelated(100ms,max=10s)":                  DecorrelatedJitterBackoff(100 * time.Millisecond).WithMaxDelay(10 * time.Second),
+		"equaljitter(base=100ms)":                      EqualJitterBackoff(100 * time.Millisecond),
+		"fixed(0s)":                                    FixedBackoff(0),
+		"fulljitter(1s,max=100ms)":                     FullJitterBackoff(time.Second).WithMaxDelay(100 * time.Millisecond),
+		"exp(1s,mult=0.5,max=0s)":                      ExponentialBackoff(time.Second).WithMultiplier(0.5).WithMaxDelay(0),
+	} {
+		b, err := ParseBackoff(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, b, s)
+	}
+}
+
+func TestParseBackoffErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"fixed",
+		"fixed(1s",
+		"linear(1s)",
+		"fixed(1 s)",
+		"fixed(1s,max=2s)",
+		"fulljitter(max=1s,500ms)",
+		"exp(initial=1s,mult=x)",
+		"exp(initial=1s,jitter=2)",
+		"exp(initial=1s,jitter=-0.1)",
+		"exp(initial=1s,jitter=0.1,foo=1)",
+	} {
+		_, err := ParseBackoff(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestBackoffBuilder_String(t *testing.T) {
+	for _, b := range []BackoffBuilder{
+		FixedBackoff(time.Second),
+		FullJitterBackoff(500 * time.Millisecond),
+		FullJitterBackoff(500 * time.Millisecond).WithMaxDelay(10 * time.Second),
+		ExponentialBackoff(100 * time.Millisecond),
+		ExponentialBackoff(100 * time.Millisecond).WithMultiplier(1.5).WithMaxDelay(30 * time.Second).WithJitter(0.2),
+		DecorrelatedJitterBackoff(100 * time.Millisecond).WithMaxDelay(10 * time.Second),
+		EqualJitterBackoff(100 * time.Millisecond),
+	} {
+		s := b.(interface{ String() string }).String()
+		parsed, err := ParseBackoff(s)
+		require.NoError(t, err, s)
+		require.Equal(t, b, parsed, s)
+	}
+
+	require.Equal(t, "exp(initial=100ms,mult=1.5,max=30s,jitter=0.2)",
+		ExponentialBackoff(100*time.Millisecond).WithMultiplier(1.5).WithMaxDelay(30*time.Second).WithJitter(0.2).String())
+	require.Equal(t, "fulljitter(500ms,max=10s)",
+		FullJitterBackoff(500*time.Millisecond).WithMaxDelay(10*time.Second).String())
+}
+
+func TestBackoffBuilder_StringRoundTrip(t *testing.T) {
+	durations := []time.Duration{0, 1, 999 * time.Microsecond, 1500 * time.Millisecond, time.Hour, -time.Second, 1<<63 - 1, -1 << 63}
+	multipliers := []float64{0, 0.5, 1, 1.5, 2, 1e-9, 1e9, -1}
+	jitters := []float64{0, 0.2, 1}
+
+	var builders []BackoffBuilder
+	for _, d := range durations {
+		builders = append(builders, FixedBackoff(d))
+		for _, max := range durations {
+			builders = append(builders,
+				FullJitterBackoff(d).WithMaxDelay(max),
+				DecorrelatedJitterBackoff(d).WithMaxDelay(max),
+				EqualJitterBackoff(d).WithMaxDelay(max),
+			)
+			for _, m := range multipliers {
+				for _, j := range jitters {
+					builders = append(builders, ExponentialBackoff(d).WithMaxDelay(max).WithMultiplier(m).WithJitter(j))
+				}
+			}
+		}
+	}
+
+	for _, b := range builders {
+		s := fmt.Sprint(b)
+		parsed, err := ParseBackoff(s)
+		require.NoError(t, err, s)
+		require.Equal(t, b, parsed, s)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("exp(initial=100ms,mult=2,max=30s,jitter=0.2);tries=10;timeout=1m")
+	require.NoError(t, err)
+	require.Equal(t, &Policy{
+		MaxTries:   10,
+		MaxElapsed: Duration(time.Minute),
+		Backoff: BackoffPolicy{
+			Kind:       BackoffExponential,
+			BaseDelay:  Duration(100 * time.Millisecond),
+			MaxDelay:   Duration(30 * time.Second),
+			Multiplier: 2,
+			Jitter:     0.2,
+		},
+	}, p)
+
+	p, err = ParsePolicy(" tries=3 ; errors_timeout=5s;reset=true;retry_after=ignore; ")
+	require.NoError(t, err)
+	require.Equal(t, &Policy{
+		MaxTries:       3,
+		ErrorsTimeout:  Duration(5 * time.Second),
+		ResetOnSuccess: true,
+		RetryAfter:     RetryAfterIgnore,
+	}, p)
+
+	p, err = ParsePolicy("")
+	require.NoError(t, err)
+	require.Equal(t, &Policy{}, p)
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for _, s := range []string{
+		"fixed(1s);fixed(2s)",
+		"tries",
+		"tries=x",
+		"tries=-1",
+		"timeout=1",
+		"retries=1",
+		"reset=maybe",
+		"linear(1s)",
+		"fixed(0s)",
+		"fulljitter(1s,max=0s)",
+		"fulljitter(2s,max=1s)",
+		"exp(1s,mult=0)",
+		"exp(1s,mult=0.5)",
+	} {
+		_, err := ParsePolicy(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestPolicy_String(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"fixed(1s)",
+		"exp(initial=100ms,max=30s,jitter=0.2);tries=10;timeout=1m0s",
+		"fulljitter(500ms);errors_timeout=5s;reset=true;retry_after=floor",
+	} {
+		p, err := ParsePolicy(s)
+		require.NoError(t, err, s)
+		require.Equal(t, s, p.String())
+	}
+
+	require.Equal(t, "", (*Policy)(nil).String())
+}
+
+func TestPolicy_Flag(t *testing.T) {
+	var p Policy
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&p, "retry", "retry policy")
+
+	require.NoError(t, fs.Parse([]string{"-retry", "fixed(10ms);tries=3"}))
+	require.Equal(t, Policy{
+		MaxTries: 3,
+		Backoff:  BackoffPolicy{Kind: BackoffFixed, BaseDelay: Duration(10 * time.Millisecond)},
+	}, p)
+	require.Equal(t, "fixed(10ms);tries=3", p.String())
+}
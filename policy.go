@@ -0,0 +1,275 @@
+package repeat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that is represented in text formats as a
+// string accepted by time.ParseDuration, e.g. "1.5s".
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("repeat: %w", err)
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// BackoffKind is a kind of backoff algorithm.
+type BackoffKind string
+
+// Supported backoff kinds.
+const (
+	BackoffFixed              BackoffKind = "fixed"
+	BackoffFullJitter         BackoffKind = "full-jitter"
+	BackoffExponential        BackoffKind = "exponential"
+	BackoffDecorrelatedJitter BackoffKind = "decorrelated-jitter"
+	BackoffEqualJitter        BackoffKind = "equal-jitter"
+)
+
+// BackoffPolicy describes a backoff algorithm and its parameters.
+type BackoffPolicy struct {
+	// Kind is the backoff algorithm.
+	//
+	// Default value is BackoffFixed.
+	Kind BackoffKind `json:"kind,omitempty"`
+
+	// BaseDelay is the delay of fixed backoff, the initial delay of
+	// exponential backoff and the base delay of the others.
+	//
+	// Default value is 1 second.
+	BaseDelay Duration `json:"base_delay,omitempty"`
+
+	// MaxDelay caps delays of all backoff kinds except fixed that does
+	// not support it. Zero value means no cap.
+	MaxDelay Duration `json:"max_delay,omitempty"`
+
+	// Multiplier is used only by exponential backoff. Zero value means
+	// the builder's default.
+	Multiplier float64 `json:"multiplier,omitempty"`
+
+	// Jitter is a randomization factor [0..1] used only by
+	// exponential backoff.
+	Jitter float64 `json:"jitter,omitempty"`
+}
+
+// Validate checks if the backoff policy is consistent.
+func (p *BackoffPolicy) Validate() error {
+	switch p.Kind {
+	case "", BackoffFixed, BackoffFullJitter, BackoffExponential, BackoffDecorrelatedJitter, BackoffEqualJitter:
+	default:
+		return fmt.Errorf("repeat: unknown backoff kind %q", p.Kind)
+	}
+
+	switch {
+	case p.BaseDelay < 0:
+		return errors.New("repeat: backoff base delay should not be negative")
+	case p.MaxDelay < 0:
+		return errors.New("repeat: backoff max delay should not be negative")
+	case p.MaxDelay != 0 && (p.Kind == "" || p.Kind == BackoffFixed):
+		return errors.New("repeat: backoff max delay is not supported by fixed backoff")
+	case p.MaxDelay > 0 && time.Duration(p.MaxDelay) < p.baseDelay():
+		return errors.New("repeat: backoff max delay should not be less than base delay")
+	case p.Multiplier != 0 && p.Kind != BackoffExponential:
+		return errors.New("repeat: backoff multiplier is supported only by exponential backoff")
+	case p.Multiplier != 0 && p.Multiplier < 1:
+		return errors.New("repeat: backoff multiplier should not be less than 1")
+	case p.Jitter != 0 && p.Kind != BackoffExponential:
+		return errors.New("repeat: backoff jitter is supported only by exponential backoff")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return errors.New("repeat: backoff jitter should be in range [0..1]")
+	}
+
+	return nil
+}
+
+// Builder returns a backoff builder configured by the policy. The
+// policy should be valid.
+func (p *BackoffPolicy) Builder() BackoffBuilder {
+	base := p.baseDelay()
+
+	switch p.Kind {
+	case BackoffFullJitter:
+		b := FullJitterBackoff(base)
+		if p.MaxDelay > 0 {
+			b.WithMaxDelay(time.Duration(p.MaxDelay))
+		}
+		return b
+
+	case BackoffExponential:
+		b := ExponentialBackoff(base).WithJitter(p.Jitter)
+		if p.MaxDelay > 0 {
+			b.WithMaxDelay(time.Duration(p.MaxDelay))
+		}
+		if p.Multiplier != 0 {
+			b.WithMultiplier(p.Multiplier)
+		}
+		return b
+
+	case BackoffDecorrelatedJitter:
+		b := DecorrelatedJitterBackoff(base)
+		if p.MaxDelay > 0 {
+			b.WithMaxDelay(time.Duration(p.MaxDelay))
+		}
+		return b
+
+	case BackoffEqualJitter:
+		b := EqualJitterBackoff(base)
+		if p.MaxDelay > 0 {
+			b.WithMaxDelay(time.Duration(p.MaxDelay))
+		}
+		return b
+
+	default:
+		return FixedBackoff(base)
+	}
+}
+
+// baseDelay returns the base delay taking into account the default
+// value.
+func (p *BackoffPolicy) baseDelay() time.Duration {
+	if p.BaseDelay > 0 {
+		return time.Duration(p.BaseDelay)
+	}
+
+	return time.Second
+}
+
+// Policy is a declarative retry policy. It can be loaded from JSON or
+// any format that can be converted to JSON, e.g. YAML.
+//
+// Example:
+//
+//	{
+//	  "max_tries": 5,
+//	  "max_elapsed": "1m",
+//	  "errors_timeout": "30s",
+//	  "backoff": {"kind": "exponential", "base_delay": "100ms", "max_delay": "10s", "jitter": 0.2},
+//	  "reset_on_success": true,
+//	  "retry_after": "floor"
+//	}
+type Policy struct {
+	// MaxTries limits the total number of calls of the operation, i.e.
+	// the first call and retries, the same way as LimitMaxAttempts. Zero
+	// value means no limit.
+	MaxTries int `json:"max_tries,omitempty"`
+
+	// MaxElapsed limits the time since the operation of the policy is
+	// created, i.e. since Policy.Repeat is called. The repetition is
+	// stopped with the last error instead of waiting if the delay would
+	// end after the limit. Zero value means no limit.
+	MaxElapsed Duration `json:"max_elapsed,omitempty"`
+
+	// ErrorsTimeout is the same as SetErrorsTimeout. Zero value means
+	// no timeout.
+	ErrorsTimeout Duration `json:"errors_timeout,omitempty"`
+
+	// Backoff specifies delays between calls.
+	Backoff BackoffPolicy `json:"backoff"`
+
+	// ResetOnSuccess is the same as SetBackoffResetOnSuccess.
+	ResetOnSuccess bool `json:"reset_on_success,omitempty"`
+
+	// RetryAfter is the same as SetRetryAfterMode.
+	RetryAfter RetryAfterMode `json:"retry_after,omitempty"`
+}
+
+// UnmarshalJSON decodes the policy and validates it.
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	type policy Policy
+	var v policy
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := (*Policy)(&v).Validate(); err != nil {
+		return err
+	}
+
+	*p = Policy(v)
+	return nil
+}
+
+// Validate checks if the policy is consistent.
+func (p *Policy) Validate() error {
+	switch {
+	case p.MaxTries < 0:
+		return errors.New("repeat: max tries should not be negative")
+	case p.MaxElapsed < 0:
+		return errors.New("repeat: max elapsed should not be negative")
+	case p.ErrorsTimeout < 0:
+		return errors.New("repeat: errors timeout should not be negative")
+	case p.RetryAfter.String() == "unknown":
+		return fmt.Errorf("repeat: unknown retry-after mode %d", int(p.RetryAfter))
+	}
+
+	return p.Backoff.Validate()
+}
+
+// DelayOptions returns WithDelay' options configured by the policy.
+func (p *Policy) DelayOptions() []func(*DelayOptions) {
+	options := []func(*DelayOptions){
+		p.Backoff.Builder().Set(),
+		SetRetryAfterMode(p.RetryAfter),
+	}
+	if p.ErrorsTimeout > 0 {
+		options = append(options, SetErrorsTimeout(time.Duration(p.ErrorsTimeout)))
+	}
+	if p.ResetOnSuccess {
+		options = append(options, SetBackoffResetOnSuccess())
+	}
+	if p.MaxElapsed > 0 {
+		options = append(options, SetMaxElapsed(time.Duration(p.MaxElapsed)))
+	}
+
+	return options
+}
+
+// Operation returns an operation that implements the policy. It stops
+// on success and should follow the repeated operation:
+//
+//	err := repeat.Repeat(op, policy.Operation())
+//
+// The passed options are applied after the policy's ones, e.g. to set a
+// context or a clock.
+//
+// The returned operation has a state. Create a new one for each
+// repetition. If the policy is invalid the operation returns the
+// validation error.
+func (p *Policy) Operation(options ...func(*DelayOptions)) Operation {
+	if err := p.Validate(); err != nil {
+		return func(error) error {
+			return err
+		}
+	}
+
+	options = append(p.DelayOptions(), options...)
+
+	ops := []Operation{StopOnSuccess()}
+	if p.MaxTries > 0 {
+		ops = append(ops, LimitMaxAttempts(p.MaxTries))
+	}
+	ops = append(ops, WithDelay(options...))
+
+	return Compose(ops...)
+}
+
+// Repeat repeats op using the policy.
+func (p *Policy) Repeat(op Operation, options ...func(*DelayOptions)) error {
+	return Repeat(op, p.Operation(options...))
+}
@@ -0,0 +1,160 @@
+package repeat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_UnmarshalJSON(t *testing.T) {
+	var p Policy
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"max_tries": 5,
+		"max_elapsed": "1m",
+		"errors_timeout": "30s",
+		"backoff": {"kind": "exponential", "base_delay": "100ms", "max_delay": "10s", "multiplier": 1.5, "jitter": 0.2},
+		"reset_on_success": true,
+		"retry_after": "floor"
+	}`), &p))
+
+	expected := Policy{
+		MaxTries:      5,
+		MaxElapsed:    Duration(time.Minute),
+		ErrorsTimeout: Duration(30 * time.Second),
+		Backoff: BackoffPolicy{
+			Kind:       BackoffExponential,
+			BaseDelay:  Duration(100 * time.Millisecond),
+			MaxDelay:   Duration(10 * time.Second),
+			Multiplier: 1.5,
+			Jitter:     0.2,
+		},
+		ResetOnSuccess: true,
+		RetryAfter:     RetryAfterFloor,
+	}
+	require.Equal(t, expected, p)
+
+	// Round trip.
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+	var p2 Policy
+	require.NoError(t, json.Unmarshal(data, &p2))
+	require.Equal(t, p, p2)
+
+	// Empty policy is valid.
+	require.NoError(t, json.Unmarshal([]byte(`{}`), &p2))
+	require.Equal(t, Policy{}, p2)
+}
+
+func TestPolicy_UnmarshalJSONErrors(t *testing.T) {
+	for _, s := range []string{
+		`{"max_tries": -1}`,
+		`{"max_elapsed": "1 minute"}`,
+		`{"max_elapsed": 100}`,
+		`{"errors_timeout": "-1s"}`,
+		`{"retry_after": "never"}`,
+		`{"backoff": {"kind": "linear"}}`,
+		`{"backoff": {"base_delay": "-1s"}}`,
+		`{"backoff": {"kind": "full-jitter", "base_delay": "2s", "max_delay": "1s"}}`,
+		`{"backoff": {"kind": "exponential", "max_delay": "500ms"}}`,
+		`{"backoff": {"kind": "fixed", "max_delay": "10s"}}`,
+		`{"backoff": {"max_delay": "10s"}}`,
+		`{"backoff": {"kind": "fixed", "jitter": 0.5}}`,
+		`{"backoff": {"kind": "equal-jitter", "multiplier": 2}}`,
+		`{"backoff": {"kind": "exponential", "multiplier": 0.5}}`,
+		`{"backoff": {"kind": "exponential", "jitter": 2}}`,
+	} {
+		var p Policy
+		require.Error(t, json.Unmarshal([]byte(s), &p), s)
+	}
+}
+
+func TestBackoffPolicy_Builder(t *testing.T) {
+	require.IsType(t, &FixedBackoffBuilder{}, (&BackoffPolicy{}).Builder())
+	require.IsType(t, &FullJitterBackoffBuilder{}, (&BackoffPolicy{Kind: BackoffFullJitter}).Builder())
+	require.IsType(t, &DecorrelatedJitterBackoffBuilder{}, (&BackoffPolicy{Kind: BackoffDecorrelatedJitter}).Builder())
+	require.IsType(t, &EqualJitterBackoffBuilder{}, (&BackoffPolicy{Kind: BackoffEqualJitter}).Builder())
+
+	b := BuildBackoff((&BackoffPolicy{
+		Kind:       BackoffExponential,
+		BaseDelay:  Duration(time.Second),
+		MaxDelay:   Duration(5 * time.Second),
+		Multiplier: 3,
+	}).Builder())
+	require.Equal(t, time.Second, b.Next())
+	require.Equal(t, 3*time.Second, b.Next())
+	require.Equal(t, 5*time.Second, b.Next())
+
+	require.Equal(t, time.Second, BuildBackoff((&BackoffPolicy{}).Builder()).Next())
+}
+
+func TestPolicy_Operation(t *testing.T) {
+	p := Policy{MaxTries: 3, Backoff: BackoffPolicy{BaseDelay: Duration(time.Millisecond)}}
+
+	calls := 0
+	err := p.Repeat(func(e error) error {
+		calls++
+		return HintTemporary(errGolden)
+	})
+	require.Equal(t, errGolden, err)
+	require.Equal(t, 3, calls)
+
+	// A single try means no retries.
+	calls = 0
+	p.MaxTries = 1
+	require.Equal(t, errGolden, p.Repeat(func(e error) error {
+		calls++
+		return HintTemporary(errGolden)
+	}))
+	require.Equal(t, 1, calls)
+
+	// Stops on success.
+	calls = 0
+	require.NoError(t, p.Repeat(func(e error) error {
+		calls++
+		return nil
+	}))
+	require.Equal(t, 1, calls)
+}
+
+func TestPolicy_MaxElapsed(t *testing.T) {
+	// The delay would end after the limit, so the op is not repeated.
+	p := Policy{MaxElapsed: Duration(time.Minute), Backoff: BackoffPolicy{BaseDelay: Duration(time.Hour)}}
+
+	calls := 0
+	require.Equal(t, errGolden, p.Repeat(func(e error) error {
+		calls++
+		return HintTemporary(errGolden)
+	}))
+	require.Equal(t, 1, calls)
+}
+
+func TestPolicy_OperationInvalid(t *testing.T) {
+	p := Policy{MaxTries: -1}
+	err := Repeat(Nope, p.Operation())
+	require.EqualError(t, err, "repeat: max tries should not be negative")
+}
+
+func TestLimitMaxElapsed(t *testing.T) {
+	// The clock starts when the operation is created.
+	op := limitMaxElapsed(2*time.Second, &tickClock{})
+	require.Equal(t, errGolden, op(errGolden))
+	require.Equal(t, HintStop(errGolden), op(errGolden))
+
+	require.True(t, IsStop(LimitMaxElapsed(0)(nil)))
+}
+
+func TestRetryAfterMode_Text(t *testing.T) {
+	for _, m := range []RetryAfterMode{RetryAfterOverride, RetryAfterFloor, RetryAfterIgnore} {
+		text, err := m.MarshalText()
+		require.NoError(t, err)
+
+		var m2 RetryAfterMode
+		require.NoError(t, m2.UnmarshalText(text))
+		require.Equal(t, m, m2)
+	}
+
+	_, err := RetryAfterMode(-1).MarshalText()
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package repeat
 
 import (
 	"context"
+	"time"
 )
 
 var (
@@ -49,8 +50,10 @@ type Repeater interface {
 }
 
 type stdRepeater struct {
-	opw  OpWrapper
-	copw OpWrapper
+	opw   OpWrapper
+	copw  OpWrapper
+	obs   Observer
+	clock Clock
 }
 
 // NewRepeater sets up everything to be able to repeat operations.
@@ -72,7 +75,24 @@ func WrapOnce(copw OpWrapper) Repeater {
 // NewRepeaterExt returns object that wraps all ops with with the given opw
 // and wraps composed operation with the given copw.
 func NewRepeaterExt(opw, copw OpWrapper) Repeater {
-	return &stdRepeater{opw, copw}
+	return &stdRepeater{opw: opw, copw: copw}
+}
+
+// Observe returns object that reports the repetition process to the
+// given Observer.
+//
+// Note! Pass the same Observer to WithDelay using SetObserver to get
+// notifications about delays.
+func Observe(o Observer) Repeater {
+	return NewObservedRepeater(Forward, Forward, o, SystemClock)
+}
+
+// NewObservedRepeater returns object that wraps all ops with with the
+// given opw, wraps composed operation with the given copw and reports
+// the repetition process to the given Observer. Durations are measured
+// using the given clock.
+func NewObservedRepeater(opw, copw OpWrapper, o Observer, c Clock) Repeater {
+	return &stdRepeater{opw: opw, copw: copw, obs: o, clock: c}
 }
 
 // Cpp returns object that calls C (constructor) at first, then ops,
@@ -102,14 +122,36 @@ func With(c, d Operation) Repeater {
 //
 // It is guaranteed that the first op will be called at least once.
 func (w *stdRepeater) Once(ops ...Operation) error {
-	return Cause(w.Compose(ops...)(nil))
+	start := w.now()
+
+	return w.stop(start, Cause(w.Compose(ops...)(nil)))
 }
 
 // Repeat repeat operations until one of them stops the repetition.
 //
 // It is guaranteed that the first op will be called at least once.
 func (w *stdRepeater) Repeat(ops ...Operation) error {
-	return Cause(w.FnRepeat(ops...)(nil))
+	start := w.now()
+
+	return w.stop(start, Cause(w.FnRepeat(ops...)(nil)))
+}
+
+// stop reports the final cause to the observer.
+func (w *stdRepeater) stop(start time.Time, cause error) error {
+	if w.obs != nil {
+		w.obs.OnStop(cause, w.now().Sub(start))
+	}
+
+	return cause
+}
+
+// now returns the current time if the repeater is observed.
+func (w *stdRepeater) now() time.Time {
+	if w.obs == nil {
+		return time.Time{}
+	}
+
+	return w.clock.Now()
 }
 
 // FnRepeat is a Repeat operation.
@@ -119,13 +161,13 @@ func (w *stdRepeater) FnRepeat(ops ...Operation) Operation {
 
 		for {
 			err = op(e)
-			switch typedError := err.(type) {
-			case nil:
+			switch {
+			case err == nil:
 				e = nil
-			case *TemporaryError:
+			case IsTemporary(err):
 				e = err
-			case *StopError:
-				switch typedError.Cause {
+			case IsStop(err):
+				switch Cause(err) {
 				case nil:
 					return nil
 				default:
@@ -141,18 +183,22 @@ func (w *stdRepeater) FnRepeat(ops ...Operation) Operation {
 // Compose wraps ops with wop and composes all passed operations info
 // a single one.
 func (w *stdRepeater) Compose(ops ...Operation) Operation {
+	if w.obs != nil && len(ops) != 0 {
+		ops = append([]Operation{fnObserveAttempts(w.obs, w.clock, ops[0])}, ops[1:]...)
+	}
+
 	return w.copw(func(e error) (err error) {
 		for _, op := range ops {
 			err = w.opw(op)(e)
-			switch err.(type) {
+			switch {
 			// Replace last E with nil.
-			case nil:
+			case err == nil:
 				e = nil
 			// Replace last E with new temporary error.
-			case *TemporaryError:
+			case IsTemporary(err):
 				e = err
 			// Stop.
-			case *StopError:
+			case IsStop(err):
 				return err
 			// Stop.
 			default:
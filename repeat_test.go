@@ -3,6 +3,7 @@ package repeat
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -388,3 +389,47 @@ func TestRepeatWithContext(t *testing.T) {
 
 	require.EqualError(t, WithContext(ctx).Once(Nope), "context canceled")
 }
+
+func TestCompose_WrappedTemporaryErrContinues(t *testing.T) {
+	cn := 0
+	require.NoError(t, Compose(
+		func(e error) error {
+			return fmt.Errorf("wrapped: %w", HintTemporary(errGolden))
+		},
+		func(e error) error {
+			cn++
+			require.True(t, IsTemporary(e))
+			return nil
+		},
+	)(nil))
+	require.Equal(t, 1, cn)
+}
+
+func TestCompose_WrappedStopErrStops(t *testing.T) {
+	require.EqualError(t, Compose(
+		func(e error) error {
+			return fmt.Errorf("wrapped: %w", HintStop(errGolden))
+		},
+		func(e error) error {
+			require.Fail(t, "should be never called")
+			return nil
+		},
+	)(nil), "wrapped: repeat.stop: golden")
+}
+
+func TestRepeat_WrappedStopErrors(t *testing.T) {
+	require.Equal(t, errGolden, Repeat(
+		FnWithCounter(func(c int) error {
+			if c == 2 {
+				return fmt.Errorf("wrapped: %w", HintStop(errGolden))
+			}
+
+			return fmt.Errorf("wrapped: %w", HintTemporary(errors.New("my temporary")))
+		}),
+	))
+	require.NoError(t, Repeat(
+		func(e error) error {
+			return fmt.Errorf("wrapped: %w", HintStop(nil))
+		},
+	))
+}
@@ -0,0 +1,268 @@
+// Package repeathttp provides an http.RoundTripper that retries
+// requests using the repeat package.
+package repeathttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ssgreg/repeat"
+)
+
+// DefaultRetryStatuses are status codes that are retried by default.
+var DefaultRetryStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// maxBufferedBody is the maximum number of bytes of a response with a
+// retried status code that is kept to return the response if there are
+// no more attempts.
+const maxBufferedBody = 64 << 10
+
+// StatusError is the cause of TemporaryError returned by an attempt
+// that received a response with a retried status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("repeathttp: unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Transport is an http.RoundTripper that retries requests with
+// idempotent methods on connection errors and retried status codes.
+// Requests with Idempotency-Key or X-Idempotency-Key header are also
+// retried. Requests with a body are retried only if GetBody is set.
+//
+// If all attempts receive a retried status code, the last response is
+// returned. Bodies of such responses are read into memory, up to
+// 64 KiB, before the delay to release the connection.
+// Other responses are drained and closed.
+//
+// Transport is safe for concurrent use if it is not modified after the
+// first request.
+type Transport struct {
+	base          http.RoundTripper
+	repeater      repeat.Repeater
+	backoff       repeat.BackoffBuilder
+	maxTries      int
+	maxRetryAfter time.Duration
+	statuses      map[int]bool
+	options       []func(*repeat.DelayOptions)
+}
+
+// NewTransport creates a Transport that sends requests with the given
+// one. http.DefaultTransport is used if base is nil.
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return (&Transport{
+		base:     base,
+		repeater: repeat.NewRepeater(),
+		backoff:  repeat.ExponentialBackoff(100 * time.Millisecond).WithMaxDelay(5 * time.Second).WithJitter(0.2),
+		maxTries: 3,
+	}).WithRetryStatuses(DefaultRetryStatuses...)
+}
+
+// WithRepeater allows to set a Repeater that repeats requests, e.g. to
+// observe attempts.
+func (t *Transport) WithRepeater(r repeat.Repeater) *Transport {
+	t.repeater = r
+	return t
+}
+
+// WithBackoff allows to set delays between attempts using one of the
+// backoff builders.
+//
+// Default value is ExponentialBackoff(100 * time.Millisecond) with
+// 5 seconds maximum delay and 0.2 jitter.
+func (t *Transport) WithBackoff(b repeat.BackoffBuilder) *Transport {
+	t.backoff = b
+	return t
+}
+
+// WithMaxTries allows to set the maximum number of attempts, i.e. the
+// first request and retries. Zero value means no limit.
+//
+// Default value is 3.
+func (t *Transport) WithMaxTries(n int) *Transport {
+	t.maxTries = n
+	return t
+}
+
+// WithMaxRetryAfter allows to limit a delay suggested by Retry-After
+// header. A response with a greater delay is returned without retries.
+// Zero value means no limit.
+//
+// Default value is 0.
+func (t *Transport) WithMaxRetryAfter(d time.Duration) *Transport {
+	t.maxRetryAfter = d
+	return t
+}
+
+// WithRetryStatuses allows to set status codes that are retried.
+//
+// Default value is DefaultRetryStatuses.
+func (t *Transport) WithRetryStatuses(codes ...int) *Transport {
+	t.statuses = make(map[int]bool, len(codes))
+	for _, code := range codes {
+		t.statuses[code] = true
+	}
+
+	return t
+}
+
+// WithDelayOptions allows to pass additional WithDelay' options, e.g.
+// repeat.SetErrorsTimeout or repeat.SetRetryAfterMode.
+func (t *Transport) WithDelayOptions(options ...func(*repeat.DelayOptions)) *Transport {
+	t.options = append(t.options, options...)
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	var resp *http.Response
+	attempt := 0
+
+	op := func(e error) error {
+		// The previous response is discarded.
+		if resp != nil {
+			drain(resp)
+			resp = nil
+		}
+
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			r = req.Clone(ctx)
+			r.Body = body
+		}
+		attempt++
+
+		res, err := t.base.RoundTrip(r)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			return repeat.HintTemporary(err)
+		case !t.statuses[res.StatusCode]:
+			resp = res
+			return nil
+		}
+
+		// Release the connection before the delay.
+		if err := bufferBody(res); err != nil {
+			return repeat.HintTemporary(err)
+		}
+
+		resp = res
+		err = &StatusError{StatusCode: res.StatusCode}
+		if d, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			if t.maxRetryAfter > 0 && d > t.maxRetryAfter {
+				return repeat.HintStop(err)
+			}
+			return repeat.HintTemporaryAfter(err, d)
+		}
+
+		return repeat.HintTemporary(err)
+	}
+
+	options := append([]func(*repeat.DelayOptions){t.backoff.Set()}, t.options...)
+	options = append(options, repeat.SetContext(ctx))
+
+	ops := []repeat.Operation{op, repeat.StopOnSuccess()}
+	if t.maxTries > 0 {
+		ops = append(ops, repeat.LimitMaxAttempts(t.maxTries))
+	}
+	ops = append(ops, repeat.WithDelay(options...))
+
+	err := t.repeater.Repeat(ops...)
+
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		return resp, nil
+	case resp != nil && ctx.Err() == nil && errors.As(err, &statusErr):
+		// Let the caller see the last response.
+		return resp, nil
+	case resp != nil:
+		drain(resp)
+	}
+
+	return nil, err
+}
+
+// retryable checks if the request can be sent again.
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+
+	return ok
+}
+
+// retryAfter parses a value of Retry-After header that is either a
+// number of seconds or an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		return time.Duration(s) * time.Second, s >= 0
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t), true
+	}
+
+	return 0, false
+}
+
+// bufferBody replaces the body of the response with its first
+// maxBufferedBody bytes read into memory and closes the original one.
+func bufferBody(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBufferedBody))
+	if err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return nil
+}
+
+// drain reads the rest of the response body to allow the connection to
+// be reused and closes it.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	_ = resp.Body.Close()
+}
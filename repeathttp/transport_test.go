@@ -0,0 +1,312 @@
+package repeathttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+var epoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// statusServer responds with the given status codes one by one and
+// then with 200.
+func statusServer(t *testing.T, calls *int32, codes ...int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(calls, 1))
+		if n <= len(codes) {
+			w.WriteHeader(codes[n-1])
+			_, _ = io.WriteString(w, "fail")
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newClient(t *Transport) *http.Client {
+	return &http.Client{Transport: t.WithBackoff(repeat.FixedBackoff(time.Millisecond))}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestTransport_RetryStatus(t *testing.T) {
+	var calls int32
+	srv := statusServer(t, &calls, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	resp, err := newClient(NewTransport(nil)).Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "ok", readBody(t, resp))
+	require.EqualValues(t, 3, calls)
+}
+
+func TestTransport_MaxTries(t *testing.T) {
+	var calls int32
+	srv := statusServer(t, &calls, 503, 503, 503, 503)
+
+	resp, err := newClient(NewTransport(nil)).Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "fail", readBody(t, resp), "the last response should be returned")
+	require.EqualValues(t, 3, calls)
+}
+
+func TestTransport_NoMaxTries(t *testing.T) {
+	var calls int32
+	srv := statusServer(t, &calls, 503, 503, 503, 503)
+
+	resp, err := newClient(NewTransport(nil).WithMaxTries(0)).Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 5, calls)
+}
+
+func TestTransport_NotRetriedStatus(t *testing.T) {
+	var calls int32
+	srv := statusServer(t, &calls, http.StatusInternalServerError)
+
+	resp, err := newClient(NewTransport(nil)).Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.EqualValues(t, 1, calls)
+
+	calls = 0
+	resp, err = newClient(NewTransport(nil).WithRetryStatuses(500)).Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 2, calls)
+}
+
+func TestTransport_Body(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		require.Equal(t, "peanut", string(data))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	client := newClient(NewTransport(nil))
+
+	// POST is not retried without an idempotency key.
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("peanut"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.EqualValues(t, 1, calls)
+
+	// The body is rewound.
+	calls = 0
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("peanut"))
+	require.NoError(t, err)
+	req.Header.Set("Idempotency-Key", "1")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 2, calls)
+
+	// The body can't be rewound without GetBody.
+	calls = 0
+	req, err = http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader("peanut")))
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.EqualValues(t, 1, calls)
+}
+
+func TestTransport_ConnectionError(t *testing.T) {
+	var calls int32
+	srv := statusServer(t, &calls)
+	errConn := errors.New("connection refused")
+
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errConn
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	resp, err := newClient(NewTransport(base)).Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, "ok", readBody(t, resp))
+
+	base = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errConn
+	})
+	calls = 0
+	_, err = newClient(NewTransport(base).WithMaxTries(2)).Get(srv.URL)
+	require.True(t, errors.Is(err, errConn))
+	require.EqualValues(t, 2, calls)
+}
+
+// closeTracker is a response body that counts Close calls.
+type closeTracker struct {
+	io.Reader
+	closed *int32
+}
+
+func (b closeTracker) Close() error {
+	atomic.AddInt32(b.closed, 1)
+	return nil
+}
+
+func TestTransport_DrainsDiscarded(t *testing.T) {
+	var calls, closed int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		code := http.StatusServiceUnavailable
+		if atomic.AddInt32(&calls, 1) == 3 {
+			code = http.StatusOK
+		}
+		return &http.Response{
+			StatusCode: code,
+			Header:     http.Header{},
+			Body:       closeTracker{strings.NewReader("body"), &closed},
+			Request:    req,
+		}, nil
+	})
+
+	resp, err := newClient(NewTransport(base)).Get("http://example.com")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 2, closed, "discarded responses should be closed")
+	require.Equal(t, "body", readBody(t, resp))
+}
+
+func TestTransport_ClosesBeforeDelay(t *testing.T) {
+	var calls, closed int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{"Retry-After": {"60"}},
+			Body:       closeTracker{strings.NewReader("body"), &closed},
+			Request:    req,
+		}, nil
+	})
+
+	c := repeattest.NewFakeClock(epoch)
+	client := newClient(NewTransport(base).WithMaxTries(2).WithDelayOptions(repeat.SetClock(c)))
+
+	res := make(chan *http.Response, 1)
+	go func() {
+		resp, err := client.Get("http://example.com")
+		require.NoError(t, err)
+		res <- resp
+	}()
+
+	c.BlockUntil(2)
+	require.EqualValues(t, 1, closed, "the response should be closed before the delay")
+	c.Advance(time.Minute)
+
+	// The body of the last response is kept.
+	resp := <-res
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "body", readBody(t, resp))
+	require.EqualValues(t, 2, calls)
+	require.EqualValues(t, 2, closed)
+}
+
+func TestTransport_RetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	c := repeattest.NewFakeClock(epoch)
+	client := newClient(NewTransport(nil).WithDelayOptions(repeat.SetClock(c)))
+
+	res := make(chan *http.Response, 1)
+	go func() {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		res <- resp
+	}()
+
+	c.BlockUntil(2)
+	c.Advance(7*time.Second - time.Nanosecond)
+	require.Len(t, res, 0)
+	c.Advance(time.Nanosecond)
+	require.Equal(t, http.StatusOK, (<-res).StatusCode)
+}
+
+func TestTransport_MaxRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	resp, err := newClient(NewTransport(nil).WithMaxRetryAfter(time.Second)).Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.EqualValues(t, 1, calls)
+}
+
+func TestTransport_Context(t *testing.T) {
+	var calls int32
+	srv := statusServer(t, &calls, 503, 503)
+
+	c := repeattest.NewFakeClock(epoch)
+	client := &http.Client{Transport: NewTransport(nil).WithDelayOptions(repeat.SetClock(c))}
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	res := make(chan error, 1)
+	go func() {
+		_, err := client.Do(req)
+		res <- err
+	}()
+
+	c.BlockUntil(2)
+	cancel()
+	require.True(t, errors.Is(<-res, context.Canceled))
+	require.EqualValues(t, 1, calls)
+}
+
+func TestRetryAfter(t *testing.T) {
+	d, ok := retryAfter("120")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, d)
+
+	d, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.InDelta(t, float64(time.Hour), float64(d), float64(2*time.Second))
+
+	for _, v := range []string{"", "-1", "soon"} {
+		_, ok = retryAfter(v)
+		require.False(t, ok, v)
+	}
+}
@@ -0,0 +1,257 @@
+// Package repeatmetrics collects metrics of repetition processes of the
+// repeat package and exposes them in the Prometheus text format.
+package repeatmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ssgreg/repeat"
+)
+
+// DefaultBuckets are upper bounds (in seconds) of histogram buckets
+// used by default.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// Collector collects metrics reported by Observers it creates. It is an
+// http.Handler that renders the metrics in the Prometheus text
+// exposition format.
+//
+// Collector is safe for concurrent use.
+type Collector struct {
+	mu       sync.Mutex
+	buckets  []float64
+	policies map[string]*policyMetrics
+}
+
+// NewCollector creates a Collector with DefaultBuckets.
+func NewCollector() *Collector {
+	return &Collector{
+		buckets:  DefaultBuckets,
+		policies: make(map[string]*policyMetrics),
+	}
+}
+
+// WithBuckets allows to set upper bounds (in seconds) of histogram
+// buckets. It should be called before any metric is collected.
+func (c *Collector) WithBuckets(buckets ...float64) *Collector {
+	c.buckets = append([]float64(nil), buckets...)
+	sort.Float64s(c.buckets)
+
+	return c
+}
+
+// Observer returns a repeat.Observer that reports metrics labelled with
+// the given policy name to the Collector. Pass it to both repeat.Observe
+// and repeat.SetObserver to collect all metrics.
+func (c *Collector) Observer(policy string) repeat.Observer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.policies[policy]; !ok {
+		c.policies[policy] = &policyMetrics{
+			delays:    newHistogram(c.buckets),
+			durations: newHistogram(c.buckets),
+		}
+	}
+
+	return &observer{c: c, policy: policy}
+}
+
+// ServeHTTP renders the metrics in the Prometheus text exposition
+// format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+// The metrics are copied first, so writing to a slow w does not block
+// observers.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	policies := c.snapshot()
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	header(bw, "repeat_attempts_total", "counter", "Total number of attempts.")
+	for _, name := range names {
+		fmt.Fprintf(bw, "repeat_attempts_total{policy=%s} %d\n", quote(name), policies[name].attempts)
+	}
+
+	header(bw, "repeat_attempt_outcomes_total", "counter", "Total number of finished attempts by outcome.")
+	for _, name := range names {
+		for kind, n := range policies[name].outcomes {
+			fmt.Fprintf(bw, "repeat_attempt_outcomes_total{policy=%s,outcome=%s} %d\n", quote(name), quote(repeat.ErrorKind(kind).String()), n)
+		}
+	}
+
+	header(bw, "repeat_runs_total", "counter", "Total number of finished repetitions by result.")
+	for _, name := range names {
+		p := policies[name]
+		fmt.Fprintf(bw, "repeat_runs_total{policy=%s,result=\"success\"} %d\n", quote(name), p.successes)
+		fmt.Fprintf(bw, "repeat_runs_total{policy=%s,result=\"failure\"} %d\n", quote(name), p.failures)
+	}
+
+	header(bw, "repeat_delay_seconds", "histogram", "Delays chosen between attempts.")
+	for _, name := range names {
+		policies[name].delays.write(bw, "repeat_delay_seconds", name)
+	}
+
+	header(bw, "repeat_run_duration_seconds", "histogram", "Durations of finished repetitions.")
+	for _, name := range names {
+		policies[name].durations.write(bw, "repeat_run_duration_seconds", name)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return cw.n, err
+	}
+
+	return cw.n, nil
+}
+
+// snapshot returns a copy of metrics of all policies.
+func (c *Collector) snapshot() map[string]*policyMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	policies := make(map[string]*policyMetrics, len(c.policies))
+	for name, p := range c.policies {
+		cp := *p
+		cp.delays, cp.durations = p.delays.clone(), p.durations.clone()
+		policies[name] = &cp
+	}
+
+	return policies
+}
+
+// policyMetrics holds metrics of a single policy.
+type policyMetrics struct {
+	attempts  uint64
+	outcomes  [4]uint64
+	successes uint64
+	failures  uint64
+	delays    *histogram
+	durations *histogram
+}
+
+type observer struct {
+	c      *Collector
+	policy string
+}
+
+func (o *observer) update(fn func(*policyMetrics)) {
+	o.c.mu.Lock()
+	defer o.c.mu.Unlock()
+
+	fn(o.c.policies[o.policy])
+}
+
+func (o *observer) OnAttemptStart(int) {
+	o.update(func(p *policyMetrics) {
+		p.attempts++
+	})
+}
+
+func (o *observer) OnAttemptEnd(_ int, kind repeat.ErrorKind, _ error, _ time.Duration) {
+	o.update(func(p *policyMetrics) {
+		if int(kind) < len(p.outcomes) {
+			p.outcomes[kind]++
+		}
+	})
+}
+
+func (o *observer) OnDelay(delay time.Duration, _ error) {
+	o.update(func(p *policyMetrics) {
+		p.delays.observe(delay.Seconds())
+	})
+}
+
+func (o *observer) OnStop(cause error, elapsed time.Duration) {
+	o.update(func(p *policyMetrics) {
+		if cause == nil {
+			p.successes++
+		} else {
+			p.failures++
+		}
+		p.durations.observe(elapsed.Seconds())
+	})
+}
+
+// histogram is a Prometheus-like histogram.
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) clone() *histogram {
+	cp := *h
+	cp.counts = append([]uint64(nil), h.counts...)
+
+	return &cp
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name, policy string) {
+	var cumulative uint64
+	for i, b := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{policy=%s,le=\"%s\"} %d\n", name, quote(policy), formatFloat(b), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{policy=%s,le=\"+Inf\"} %d\n", name, quote(policy), h.count)
+	fmt.Fprintf(w, "%s_sum{policy=%s} %s\n", name, quote(policy), formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count{policy=%s} %d\n", name, quote(policy), h.count)
+}
+
+func header(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// quote quotes a label value according to the text exposition format.
+func quote(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+
+	return n, err
+}
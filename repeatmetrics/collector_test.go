@@ -0,0 +1,168 @@
+package repeatmetrics
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector().WithBuckets(1, 0.1)
+	o := c.Observer("db")
+
+	errGolden := errors.New("golden")
+	require.Equal(t, errGolden, repeat.Observe(o).Repeat(
+		repeat.FnWithCounter(func(c int) error {
+			switch c {
+			case 0, 1:
+				return repeat.HintTemporary(errors.New("busy"))
+			case 2:
+				return nil
+			default:
+				return errGolden
+			}
+		}),
+		repeat.WithDelay(repeat.FixedBackoff(0).Set(), repeat.SetObserver(o)),
+	))
+	require.NoError(t, repeat.Observe(o).Once(repeat.Nope))
+
+	buf := &bytes.Buffer{}
+	n, err := c.WriteTo(buf)
+	require.NoError(t, err)
+	require.EqualValues(t, buf.Len(), n)
+
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE repeat_attempts_total counter",
+		`repeat_attempts_total{policy="db"} 5`,
+		`repeat_attempt_outcomes_total{policy="db",outcome="success"} 2`,
+		`repeat_attempt_outcomes_total{policy="db",outcome="temporary"} 2`,
+		`repeat_attempt_outcomes_total{policy="db",outcome="stop"} 0`,
+		`repeat_attempt_outcomes_total{policy="db",outcome="fatal"} 1`,
+		`repeat_runs_total{policy="db",result="success"} 1`,
+		`repeat_runs_total{policy="db",result="failure"} 1`,
+		"# TYPE repeat_delay_seconds histogram",
+		`repeat_delay_seconds_bucket{policy="db",le="0.1"} 3`,
+		`repeat_delay_seconds_bucket{policy="db",le="1"} 3`,
+		`repeat_delay_seconds_bucket{policy="db",le="+Inf"} 3`,
+		`repeat_delay_seconds_sum{policy="db"} 0`,
+		`repeat_delay_seconds_count{policy="db"} 3`,
+		"# TYPE repeat_run_duration_seconds histogram",
+		`repeat_run_duration_seconds_bucket{policy="db",le="+Inf"} 2`,
+		`repeat_run_duration_seconds_count{policy="db"} 2`,
+	} {
+		require.Contains(t, out, line+"\n")
+	}
+}
+
+func TestCollector_Histogram(t *testing.T) {
+	c := NewCollector().WithBuckets(0.5, 2)
+	o := c.Observer("api")
+	o.OnDelay(100*time.Millisecond, nil)
+	o.OnDelay(time.Second, nil)
+	o.OnDelay(time.Minute, nil)
+
+	buf := &bytes.Buffer{}
+	_, err := c.WriteTo(buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), strings.Join([]string{
+		`repeat_delay_seconds_bucket{policy="api",le="0.5"} 1`,
+		`repeat_delay_seconds_bucket{policy="api",le="2"} 2`,
+		`repeat_delay_seconds_bucket{policy="api",le="+Inf"} 3`,
+		`repeat_delay_seconds_sum{policy="api"} 61.1`,
+		`repeat_delay_seconds_count{policy="api"} 3`,
+	}, "\n"))
+}
+
+func TestCollector_SortedAndEscaped(t *testing.T) {
+	c := NewCollector()
+	c.Observer("b").OnAttemptStart(0)
+	c.Observer("a \"x\"\\\n").OnAttemptStart(0)
+	c.Observer("b").OnAttemptStart(1)
+
+	buf := &bytes.Buffer{}
+	_, err := c.WriteTo(buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), strings.Join([]string{
+		`repeat_attempts_total{policy="a \"x\"\\\n"} 1`,
+		`repeat_attempts_total{policy="b"} 2`,
+	}, "\n"))
+}
+
+func TestCollector_ServeHTTP(t *testing.T) {
+	c := NewCollector()
+	c.Observer("db").OnStop(nil, time.Second)
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	rsp, err := srv.Client().Get(srv.URL)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rsp.Header.Get("Content-Type"))
+	require.Contains(t, string(body), `repeat_runs_total{policy="db",result="success"} 1`)
+	require.Contains(t, string(body), `repeat_run_duration_seconds_sum{policy="db"} 1`)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestCollector_WriteError(t *testing.T) {
+	_, err := NewCollector().WriteTo(failingWriter{})
+	require.EqualError(t, err, "broken pipe")
+}
+
+// blockingWriter blocks writes until release is closed.
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.release
+
+	return len(p), nil
+}
+
+func TestCollector_SlowWriter(t *testing.T) {
+	c := NewCollector()
+	o := c.Observer("db")
+
+	w := &blockingWriter{started: make(chan struct{}, 1), release: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.WriteTo(w)
+		done <- err
+	}()
+	<-w.started
+
+	// Observers are not blocked by the stalled writer.
+	o.OnAttemptStart(0)
+	o.OnStop(nil, time.Second)
+
+	close(w.release)
+	require.NoError(t, <-done)
+
+	var buf bytes.Buffer
+	_, err := c.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `repeat_attempts_total{policy="db"} 1`)
+}
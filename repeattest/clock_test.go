@@ -0,0 +1,69 @@
+package repeattest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClock_Now(t *testing.T) {
+	c := NewFakeClock(epoch)
+	require.Equal(t, epoch, c.Now())
+
+	c.Advance(time.Hour)
+	require.Equal(t, epoch.Add(time.Hour), c.Now())
+}
+
+func TestFakeClock_Timer(t *testing.T) {
+	c := NewFakeClock(epoch)
+	tm := c.NewTimer(time.Second)
+	require.Equal(t, 1, c.Timers())
+
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-tm.C():
+		require.Fail(t, "timer should not fire before deadline")
+	default:
+	}
+
+	c.Advance(time.Millisecond)
+	require.Equal(t, epoch.Add(time.Second), <-tm.C())
+	require.Equal(t, 0, c.Timers())
+	require.False(t, tm.Stop())
+}
+
+func TestFakeClock_TimerStop(t *testing.T) {
+	c := NewFakeClock(epoch)
+	tm := c.NewTimer(time.Second)
+	require.True(t, tm.Stop())
+	require.Equal(t, 0, c.Timers())
+
+	c.Advance(time.Second)
+	select {
+	case <-tm.C():
+		require.Fail(t, "stopped timer should not fire")
+	default:
+	}
+}
+
+func TestFakeClock_NonPositiveDuration(t *testing.T) {
+	c := NewFakeClock(epoch)
+	require.Equal(t, epoch, <-c.After(0))
+	require.Equal(t, epoch, <-c.After(-time.Second))
+	require.Equal(t, 0, c.Timers())
+}
+
+func TestFakeClock_BlockUntil(t *testing.T) {
+	c := NewFakeClock(epoch)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-c.After(time.Minute)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	require.Equal(t, epoch.Add(time.Minute), <-done)
+}
@@ -0,0 +1,164 @@
+package repeat
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Simulation holds delays produced by a backoff algorithm over many
+// independent runs.
+type Simulation struct {
+	// Samples holds delays by attempt: Samples[attempt][run].
+	Samples [][]time.Duration
+}
+
+// DelayStats describes delays of a single attempt.
+type DelayStats struct {
+	// Attempt is the number of the attempt the delay follows starting
+	// from 0.
+	Attempt int
+
+	Min    time.Duration
+	Median time.Duration
+	P99    time.Duration
+	Max    time.Duration
+
+	// CumulativeMedian and CumulativeP99 describe the total wait
+	// including all delays up to this attempt. The total wait is
+	// limited by the maximum time.Duration.
+	CumulativeMedian time.Duration
+	CumulativeP99    time.Duration
+}
+
+// SimulateBackoff samples the first attempts delays of a backoff
+// created by the given builder in runs independent runs. It allows to
+// see what the backoff actually produces.
+func SimulateBackoff(b BackoffBuilder, attempts, runs int) *Simulation {
+	s := &Simulation{Samples: make([][]time.Duration, attempts)}
+	for i := range s.Samples {
+		s.Samples[i] = make([]time.Duration, runs)
+	}
+
+	for run := 0; run < runs; run++ {
+		backoff := BuildBackoff(b)
+		for attempt := 0; attempt < attempts; attempt++ {
+			s.Samples[attempt][run] = backoff.Next()
+		}
+	}
+
+	return s
+}
+
+// Stats returns delay statistics for each attempt.
+func (s *Simulation) Stats() []DelayStats {
+	stats := make([]DelayStats, len(s.Samples))
+	var cumulative []time.Duration
+	for attempt, samples := range s.Samples {
+		if cumulative == nil {
+			cumulative = make([]time.Duration, len(samples))
+		}
+		for run, d := range samples {
+			cumulative[run] = addSaturated(cumulative[run], d)
+		}
+
+		sorted := sortedDurations(samples)
+		sortedCumulative := sortedDurations(cumulative)
+		stats[attempt] = DelayStats{
+			Attempt:          attempt,
+			Min:              percentile(sorted, 0),
+			Median:           percentile(sorted, 0.5),
+			P99:              percentile(sorted, 0.99),
+			Max:              percentile(sorted, 1),
+			CumulativeMedian: percentile(sortedCumulative, 0.5),
+			CumulativeP99:    percentile(sortedCumulative, 0.99),
+		}
+	}
+
+	return stats
+}
+
+// Histogram splits delays of the given attempt into n buckets of equal
+// width between the minimum and the maximum delay. It returns the
+// inclusive upper bounds of buckets and the number of delays in each
+// of them. The first bucket also includes the minimum delay.
+func (s *Simulation) Histogram(attempt, n int) ([]time.Duration, []int) {
+	samples := s.Samples[attempt]
+	if len(samples) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	sorted := sortedDurations(samples)
+	min, max := sorted[0], sorted[len(sorted)-1]
+	width := (max - min) / time.Duration(n)
+	if width == 0 {
+		width = 1
+	}
+
+	// Bounds do not exceed the maximum delay, so they do not decrease
+	// even if there are fewer distinct values than buckets.
+	bounds := make([]time.Duration, n)
+	for i := range bounds {
+		bounds[i] = max
+		if i < int((max-min)/width) {
+			bounds[i] = min + width*time.Duration(i+1)
+		}
+	}
+	bounds[n-1] = max
+
+	counts := make([]int, n)
+	for _, d := range samples {
+		// A delay equal to the upper bound belongs to the bucket, i.e.
+		// the index is ceil((d-min)/width)-1.
+		i := int((d - min) / width)
+		if (d-min)%width == 0 {
+			i--
+		}
+		switch {
+		case i < 0:
+			i = 0
+		case i >= n:
+			i = n - 1
+		}
+		counts[i]++
+	}
+
+	return bounds, counts
+}
+
+// addSaturated returns a+b limited by the range of time.Duration.
+func addSaturated(a, b time.Duration) time.Duration {
+	sum := a + b
+	switch {
+	case b > 0 && sum < a:
+		return math.MaxInt64
+	case b < 0 && sum > a:
+		return math.MinInt64
+	}
+
+	return sum
+}
+
+func sortedDurations(ds []time.Duration) []time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted
+}
+
+// percentile returns the nearest-rank percentile p [0..1] of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	switch {
+	case i < 0:
+		i = 0
+	case i >= len(sorted):
+		i = len(sorted) - 1
+	}
+
+	return sorted[i]
+}
@@ -0,0 +1,82 @@
+package repeat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateBackoff_Fixed(t *testing.T) {
+	s := SimulateBackoff(FixedBackoff(time.Second), 3, 10)
+	require.Len(t, s.Samples, 3)
+	require.Len(t, s.Samples[0], 10)
+
+	require.Equal(t, []DelayStats{
+		{0, time.Second, time.Second, time.Second, time.Second, time.Second, time.Second},
+		{1, time.Second, time.Second, time.Second, time.Second, 2 * time.Second, 2 * time.Second},
+		{2, time.Second, time.Second, time.Second, time.Second, 3 * time.Second, 3 * time.Second},
+	}, s.Stats())
+}
+
+func TestSimulateBackoff_Exponential(t *testing.T) {
+	stats := SimulateBackoff(ExponentialBackoff(time.Second).WithMaxDelay(3*time.Second), 3, 5).Stats()
+	require.Equal(t, time.Second, stats[0].Median)
+	require.Equal(t, 2*time.Second, stats[1].Median)
+	require.Equal(t, 3*time.Second, stats[2].Median)
+	require.Equal(t, 6*time.Second, stats[2].CumulativeMedian)
+}
+
+func TestSimulateBackoff_FullJitter(t *testing.T) {
+	stats := SimulateBackoff(FullJitterBackoff(time.Second).WithMaxDelay(4*time.Second), 5, 1000).Stats()
+	for _, s := range stats {
+		require.True(t, s.Min <= s.Median && s.Median <= s.P99 && s.P99 <= s.Max, "%+v", s)
+		require.True(t, s.Max < 4*time.Second, "%+v", s)
+		require.True(t, s.CumulativeMedian <= s.CumulativeP99, "%+v", s)
+	}
+	require.True(t, stats[0].Max < time.Second)
+}
+
+func TestSimulation_StatsSaturated(t *testing.T) {
+	const maxDuration = time.Duration(1<<63 - 1)
+	s := &Simulation{Samples: [][]time.Duration{{maxDuration / 2}, {maxDuration / 2}, {maxDuration}}}
+
+	stats := s.Stats()
+	require.Equal(t, maxDuration-1, stats[1].CumulativeMedian)
+	require.Equal(t, maxDuration, stats[2].CumulativeMedian)
+	require.Equal(t, maxDuration, stats[2].CumulativeP99)
+}
+
+func TestSimulation_Histogram(t *testing.T) {
+	s := &Simulation{Samples: [][]time.Duration{{0, 1, 2, 3, 4, 5, 6, 7, 8, 10}}}
+
+	// Bounds are inclusive.
+	bounds, counts := s.Histogram(0, 5)
+	require.Equal(t, []time.Duration{2, 4, 6, 8, 10}, bounds)
+	require.Equal(t, []int{3, 2, 2, 2, 1}, counts)
+
+	// Fewer distinct values than buckets.
+	s = &Simulation{Samples: [][]time.Duration{{2, 3, 3, 4}}}
+	bounds, counts = s.Histogram(0, 4)
+	require.Equal(t, []time.Duration{3, 4, 4, 4}, bounds)
+	require.Equal(t, []int{3, 1, 0, 0}, counts)
+
+	// All delays are the same.
+	s = &Simulation{Samples: [][]time.Duration{{5, 5}}}
+	bounds, counts = s.Histogram(0, 3)
+	require.Equal(t, []time.Duration{5, 5, 5}, bounds)
+	require.Equal(t, []int{2, 0, 0}, counts)
+
+	bounds, counts = s.Histogram(0, 0)
+	require.Nil(t, bounds)
+	require.Nil(t, counts)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4}
+	require.EqualValues(t, 1, percentile(sorted, 0))
+	require.EqualValues(t, 2, percentile(sorted, 0.5))
+	require.EqualValues(t, 4, percentile(sorted, 0.99))
+	require.EqualValues(t, 4, percentile(sorted, 1))
+	require.EqualValues(t, 0, percentile(nil, 0.5))
+}
@@ -0,0 +1,15 @@
+module github.com/ssgreg/repeat/slogrepeat
+
+go 1.21
+
+require (
+	github.com/ssgreg/repeat v1.5.0
+	github.com/stretchr/testify v1.3.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
+
+replace github.com/ssgreg/repeat => ../
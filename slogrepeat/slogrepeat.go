@@ -0,0 +1,116 @@
+// Package slogrepeat emits structured log/slog records about repetition
+// processes of the repeat package.
+package slogrepeat
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ssgreg/repeat"
+)
+
+// Keys of attributes of emitted records.
+const (
+	AttemptKey = "attempt"
+	KindKey    = "kind"
+	CauseKey   = "cause"
+	DelayKey   = "delay"
+	ElapsedKey = "elapsed"
+)
+
+// Observer is a repeat.Observer that emits a slog record for each
+// notification.
+type Observer struct {
+	logger *slog.Logger
+}
+
+// NewObserver creates an Observer that logs using the given logger.
+func NewObserver(logger *slog.Logger) *Observer {
+	return &Observer{logger}
+}
+
+// OnAttemptStart emits a debug record.
+func (o *Observer) OnAttemptStart(attempt int) {
+	o.logger.LogAttrs(context.Background(), slog.LevelDebug, "repeat: attempt started",
+		slog.Int(AttemptKey, attempt))
+}
+
+// OnAttemptEnd emits a record with the level depending on kind.
+func (o *Observer) OnAttemptEnd(attempt int, kind repeat.ErrorKind, err error, elapsed time.Duration) {
+	logAttempt(o.logger, attempt, kind, err, elapsed)
+}
+
+// OnDelay emits a debug record.
+func (o *Observer) OnDelay(delay time.Duration, e error) {
+	attrs := []slog.Attr{slog.Duration(DelayKey, delay)}
+	if cause := repeat.Cause(e); cause != nil {
+		attrs = append(attrs, slog.Any(CauseKey, cause))
+	}
+
+	o.logger.LogAttrs(context.Background(), slog.LevelDebug, "repeat: delay", attrs...)
+}
+
+// OnStop emits an info record in case of success and an error record
+// otherwise.
+func (o *Observer) OnStop(cause error, elapsed time.Duration) {
+	if cause == nil {
+		o.logger.LogAttrs(context.Background(), slog.LevelInfo, "repeat: finished",
+			slog.Duration(ElapsedKey, elapsed))
+		return
+	}
+
+	o.logger.LogAttrs(context.Background(), slog.LevelError, "repeat: finished",
+		slog.Any(CauseKey, cause), slog.Duration(ElapsedKey, elapsed))
+}
+
+// Wrap returns an OpWrapper that emits a record for each call of a
+// wrapped operation. Calls are counted separately for each wrapped
+// operation. Wrap either a single operation or a composed operation
+// using repeat.WrapOnce, e.g.:
+//
+//	repeat.Repeat(slogrepeat.Wrap(logger)(op), repeat.StopOnSuccess())
+//	repeat.WrapOnce(slogrepeat.Wrap(logger)).Repeat(op, repeat.StopOnSuccess())
+//
+// Use repeat.Observe with NewObserver to log attempts of the first
+// operation only.
+func Wrap(logger *slog.Logger) repeat.OpWrapper {
+	return func(op repeat.Operation) repeat.Operation {
+		attempt := 0
+		return func(e error) error {
+			defer func() { attempt++ }()
+
+			start := time.Now()
+			err := op(e)
+			logAttempt(logger, attempt, repeat.Classify(err), err, time.Since(start))
+
+			return err
+		}
+	}
+}
+
+// logAttempt emits a record about a finished attempt.
+func logAttempt(logger *slog.Logger, attempt int, kind repeat.ErrorKind, err error, elapsed time.Duration) {
+	attrs := []slog.Attr{
+		slog.Int(AttemptKey, attempt),
+		slog.String(KindKey, kind.String()),
+		slog.Duration(ElapsedKey, elapsed),
+	}
+	if cause := repeat.Cause(err); cause != nil {
+		attrs = append(attrs, slog.Any(CauseKey, cause))
+	}
+
+	logger.LogAttrs(context.Background(), level(kind), "repeat: attempt finished", attrs...)
+}
+
+// level returns a level of a record about an attempt of the given kind.
+func level(kind repeat.ErrorKind) slog.Level {
+	switch kind {
+	case repeat.KindSuccess, repeat.KindStop:
+		return slog.LevelDebug
+	case repeat.KindTemporary:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
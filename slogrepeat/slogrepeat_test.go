@@ -0,0 +1,125 @@
+package slogrepeat
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+)
+
+var errGolden = errors.New("golden")
+
+// newLogger creates a logger that writes records without time and
+// elapsed attributes to buf.
+func newLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == ElapsedKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func lines(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestObserver(t *testing.T) {
+	buf := &bytes.Buffer{}
+	o := NewObserver(newLogger(buf))
+
+	require.Equal(t, errGolden, repeat.Observe(o).Repeat(
+		repeat.FnWithCounter(func(c int) error {
+			switch c {
+			case 0:
+				return repeat.HintTemporary(errors.New("busy"))
+			case 1:
+				return nil
+			default:
+				return errGolden
+			}
+		}),
+		repeat.WithDelay(repeat.FixedBackoff(time.Millisecond).Set(), repeat.SetObserver(o)),
+	))
+
+	require.Equal(t, []string{
+		`level=DEBUG msg="repeat: attempt started" attempt=0`,
+		`level=WARN msg="repeat: attempt finished" attempt=0 kind=temporary cause=busy`,
+		`level=DEBUG msg="repeat: delay" delay=1ms cause=busy`,
+		`level=DEBUG msg="repeat: attempt started" attempt=1`,
+		`level=DEBUG msg="repeat: attempt finished" attempt=1 kind=success`,
+		`level=DEBUG msg="repeat: delay" delay=1ms`,
+		`level=DEBUG msg="repeat: attempt started" attempt=2`,
+		`level=ERROR msg="repeat: attempt finished" attempt=2 kind=fatal cause=golden`,
+		`level=ERROR msg="repeat: finished" cause=golden`,
+	}, lines(buf))
+}
+
+func TestObserver_Success(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, repeat.Observe(NewObserver(newLogger(buf))).Once(repeat.HintStop))
+	require.Equal(t, []string{
+		`level=DEBUG msg="repeat: attempt started" attempt=0`,
+		`level=DEBUG msg="repeat: attempt finished" attempt=0 kind=stop`,
+		`level=INFO msg="repeat: finished"`,
+	}, lines(buf))
+}
+
+func TestWrap(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.Equal(t, errGolden, repeat.Repeat(
+		Wrap(newLogger(buf))(repeat.FnWithCounter(func(c int) error {
+			if c < 2 {
+				return repeat.HintTemporary(errors.New("busy"))
+			}
+
+			return repeat.HintStop(errGolden)
+		})),
+		repeat.WithDelay(repeat.FixedBackoff(0).Set()),
+	))
+	require.Equal(t, []string{
+		`level=WARN msg="repeat: attempt finished" attempt=0 kind=temporary cause=busy`,
+		`level=WARN msg="repeat: attempt finished" attempt=1 kind=temporary cause=busy`,
+		`level=DEBUG msg="repeat: attempt finished" attempt=2 kind=stop cause=golden`,
+	}, lines(buf))
+}
+
+func TestWrap_Once(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := repeat.WrapOnce(Wrap(newLogger(buf)))
+	require.NoError(t, r.Once(repeat.Nope, repeat.Nope))
+	require.NoError(t, r.Once(repeat.Nope))
+	require.Equal(t, []string{
+		`level=DEBUG msg="repeat: attempt finished" attempt=0 kind=success`,
+		`level=DEBUG msg="repeat: attempt finished" attempt=0 kind=success`,
+	}, lines(buf))
+}
+
+func TestWrap_RepeatOnce(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, repeat.WrapOnce(Wrap(newLogger(buf))).Repeat(
+		repeat.FnWithCounter(func(c int) error {
+			if c < 2 {
+				return repeat.HintTemporary(errors.New("busy"))
+			}
+
+			return nil
+		}),
+		repeat.StopOnSuccess(),
+		repeat.WithDelay(repeat.FixedBackoff(0).Set()),
+	))
+	require.Equal(t, []string{
+		`level=WARN msg="repeat: attempt finished" attempt=0 kind=temporary cause=busy`,
+		`level=WARN msg="repeat: attempt finished" attempt=1 kind=temporary cause=busy`,
+		`level=DEBUG msg="repeat: attempt finished" attempt=2 kind=stop`,
+	}, lines(buf))
+}
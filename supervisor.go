@@ -0,0 +1,311 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrRestartIntensity is returned by Supervisor when workers are
+// restarted too often.
+var ErrRestartIntensity = errors.New("repeat: restart intensity is exceeded")
+
+// Worker is a long-running function run by Supervisor. It should
+// return when ctx is done.
+//
+// Returning nil or StopError without a cause means the worker is
+// finished and must not be restarted. Returning StopError with a cause
+// stops the whole supervisor. Any other error or a panic means the
+// worker failed and must be restarted.
+type Worker func(ctx context.Context) error
+
+// WorkerError holds an error returned by the named worker.
+type WorkerError struct {
+	Name string
+	Err  error
+}
+
+func (e *WorkerError) Error() string {
+	return fmt.Sprintf("worker %q: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the error of the worker.
+func (e *WorkerError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError holds a value a worker panicked with.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("repeat: panic: %v", e.Value)
+}
+
+// SupervisorStrategy specifies which workers are restarted when one of
+// them fails.
+type SupervisorStrategy int
+
+const (
+	// OneForOne restarts only the failed worker.
+	OneForOne SupervisorStrategy = iota
+
+	// OneForAll stops all running workers and restarts all of them
+	// when one of them fails.
+	OneForAll
+)
+
+func (s SupervisorStrategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	default:
+		return "unknown"
+	}
+}
+
+// SetSupervisorStrategy specifies the restart strategy.
+//
+// Default value is OneForOne.
+func SetSupervisorStrategy(s SupervisorStrategy) func(*SupervisorOptions) {
+	return func(o *SupervisorOptions) {
+		o.Strategy = s
+	}
+}
+
+// SetSupervisorIntensity specifies the maximum number of restarts in
+// the given period. The supervisor stops all workers and returns
+// ErrRestartIntensity when there are more restarts.
+//
+// Default value is 5 restarts in 1 minute.
+func SetSupervisorIntensity(restarts int, period time.Duration) func(*SupervisorOptions) {
+	return func(o *SupervisorOptions) {
+		o.MaxRestarts = restarts
+		o.Period = period
+	}
+}
+
+// SetSupervisorBackoff specifies delays before restarts using one of
+// the backoff builders. Each worker has its own backoff sequence, with
+// OneForAll strategy all workers share one. It is started over when
+// the failed worker ran at least the intensity period.
+//
+// Default value is ExponentialBackoff(100 * time.Millisecond) with
+// 10 seconds maximum delay.
+func SetSupervisorBackoff(b BackoffBuilder) func(*SupervisorOptions) {
+	return func(o *SupervisorOptions) {
+		o.Backoff = b
+	}
+}
+
+// SetSupervisorClock allows to set a clock instead of the system one.
+func SetSupervisorClock(c Clock) func(*SupervisorOptions) {
+	return func(o *SupervisorOptions) {
+		o.Clock = c
+	}
+}
+
+// SupervisorOptions holds parameters for a supervisor.
+type SupervisorOptions struct {
+	Strategy    SupervisorStrategy
+	MaxRestarts int
+	Period      time.Duration
+	Backoff     BackoffBuilder
+	Clock       Clock
+}
+
+// Supervisor runs a set of named workers and restarts failed ones.
+type Supervisor struct {
+	o        SupervisorOptions
+	children []*child
+}
+
+type child struct {
+	name   string
+	worker Worker
+}
+
+// exit is a result of a worker run.
+type exit struct {
+	i   int
+	err error
+}
+
+// NewSupervisor creates a Supervisor without workers.
+func NewSupervisor(options ...func(*SupervisorOptions)) *Supervisor {
+	o := SupervisorOptions{
+		MaxRestarts: 5,
+		Period:      time.Minute,
+		Backoff:     ExponentialBackoff(100 * time.Millisecond).WithMaxDelay(10 * time.Second),
+		Clock:       SystemClock,
+	}
+	for _, option := range options {
+		option(&o)
+	}
+
+	return &Supervisor{o: o}
+}
+
+// Add adds the named worker. It must be called before Run.
+func (s *Supervisor) Add(name string, w Worker) *Supervisor {
+	s.children = append(s.children, &child{name, w})
+
+	return s
+}
+
+// Run starts all workers and supervises them until:
+//   - ctx is done: nil;
+//   - all workers are finished: nil;
+//   - a worker returns StopError with a cause: WorkerError;
+//   - workers are restarted too often: ErrRestartIntensity wrapping
+//     WorkerError of the last failure.
+//
+// Run always stops all workers and waits for them before return.
+func (s *Supervisor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Workers that are restarted together.
+	groups := [][]*child{s.children}
+	if s.o.Strategy == OneForOne {
+		groups = make([][]*child, len(s.children))
+		for i, c := range s.children {
+			groups[i] = []*child{c}
+		}
+	}
+
+	history := &restartHistory{max: s.o.MaxRestarts, period: s.o.Period}
+	results := make(chan error, len(groups))
+	for _, g := range groups {
+		go func(g []*child) {
+			b := BuildBackoff(s.o.Backoff)
+			results <- Repeat(
+				s.fnGroup(ctx, g, b, history),
+				WithDelay(
+					func(do *DelayOptions) { do.setBackoff(b) },
+					SetContext(ctx),
+					SetContextHintStop(),
+					SetClock(s.o.Clock),
+				),
+			)
+		}(g)
+	}
+
+	var result error
+	for range groups {
+		if err := <-results; err != nil && result == nil {
+			// Stop the rest of groups.
+			result = err
+			cancel()
+		}
+	}
+
+	return result
+}
+
+// fnGroup makes an Operation that runs the group of workers until all
+// of them are finished or one of them fails. The rest of the group is
+// stopped on failure, so the whole group is restarted by the next call.
+//
+// The operation returns:
+//   - all workers are finished or ctx is done: StopError without a
+//     cause;
+//   - a worker returns StopError with a cause: StopError with
+//     WorkerError;
+//   - workers are restarted too often: StopError with
+//     ErrRestartIntensity;
+//   - otherwise: TemporaryError with WorkerError.
+func (s *Supervisor) fnGroup(ctx context.Context, cs []*child, b Backoff, h *restartHistory) Operation {
+	finished := make([]bool, len(cs))
+
+	return func(error) error {
+		gctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		started := s.o.Clock.Now()
+		exits := make(chan exit, len(cs))
+		running := 0
+		for i, c := range cs {
+			if !finished[i] {
+				running++
+				go func(i int, w Worker) {
+					exits <- exit{i, callWorker(gctx, w)}
+				}(i, c.worker)
+			}
+		}
+
+		var result error
+		for ; running > 0; running-- {
+			x := <-exits
+			werr := &WorkerError{cs[x.i].name, Cause(x.err)}
+
+			switch err := x.err; {
+			case gctx.Err() != nil:
+				// The worker is stopped with the rest of the group.
+				continue
+			case err == nil || IsStop(err) && Cause(err) == nil:
+				finished[x.i] = true
+				continue
+			case IsStop(err):
+				result = HintStop(werr)
+			default:
+				now := s.o.Clock.Now()
+				if h.add(now) {
+					if now.Sub(started) >= s.o.Period {
+						b.Reset()
+					}
+					result = HintTemporary(werr)
+				} else {
+					result = HintStop(fmt.Errorf("%w: %w", ErrRestartIntensity, werr))
+				}
+			}
+			cancel()
+		}
+
+		if result == nil || ctx.Err() != nil && IsTemporary(result) {
+			return HintStop(nil)
+		}
+
+		return result
+	}
+}
+
+// restartHistory holds restarts of all workers within the intensity
+// period.
+type restartHistory struct {
+	mu     sync.Mutex
+	times  []time.Time
+	max    int
+	period time.Duration
+}
+
+// add adds a restart and reports whether the intensity is not exceeded.
+func (h *restartHistory) add(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.times = append(h.times, now)
+	for len(h.times) > 0 && !h.times[0].After(now.Add(-h.period)) {
+		h.times = h.times[1:]
+	}
+
+	return len(h.times) <= h.max
+}
+
+// callWorker calls w turning a panic into PanicError.
+func callWorker(ctx context.Context, w Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return w(ctx)
+}
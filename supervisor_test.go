@@ -0,0 +1,179 @@
+package repeat_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ssgreg/repeat"
+	"github.com/ssgreg/repeat/repeattest"
+)
+
+// scriptedWorker returns a Worker that reports each start to starts and
+// then returns the next error from results or, if there is no one,
+// runs until ctx is done.
+func scriptedWorker(starts chan<- string, name string, results ...error) repeat.Worker {
+	return func(ctx context.Context) error {
+		starts <- name
+		if len(results) > 0 {
+			err := results[0]
+			results = results[1:]
+			return err
+		}
+
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func goRun(ctx context.Context, s *repeat.Supervisor) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- s.Run(ctx)
+	}()
+
+	return ch
+}
+
+func TestSupervisor_OneForOne(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	starts := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := repeat.NewSupervisor(
+		repeat.SetSupervisorBackoff(repeat.FixedBackoff(time.Second)),
+		repeat.SetSupervisorClock(c),
+	).
+		Add("a", scriptedWorker(starts, "a", errPeanut)).
+		Add("b", scriptedWorker(starts, "b"))
+
+	res := goRun(ctx, s)
+	require.ElementsMatch(t, []string{"a", "b"}, []string{<-starts, <-starts})
+
+	c.BlockUntil(2)
+	c.Advance(time.Second)
+	require.Equal(t, "a", <-starts)
+	require.Len(t, starts, 0, "b should not be restarted")
+
+	cancel()
+	require.NoError(t, <-res)
+}
+
+func TestSupervisor_OneForAll(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	starts := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := repeat.NewSupervisor(
+		repeat.SetSupervisorStrategy(repeat.OneForAll),
+		repeat.SetSupervisorBackoff(repeat.FixedBackoff(time.Second)),
+		repeat.SetSupervisorClock(c),
+	).
+		Add("a", scriptedWorker(starts, "a", errPeanut)).
+		Add("b", scriptedWorker(starts, "b"))
+
+	res := goRun(ctx, s)
+	require.ElementsMatch(t, []string{"a", "b"}, []string{<-starts, <-starts})
+
+	// b is restarted too.
+	c.BlockUntil(2)
+	c.Advance(time.Second)
+	require.ElementsMatch(t, []string{"a", "b"}, []string{<-starts, <-starts})
+
+	cancel()
+	require.NoError(t, <-res)
+}
+
+func TestSupervisor_Intensity(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	starts := make(chan string, 10)
+
+	s := repeat.NewSupervisor(
+		repeat.SetSupervisorIntensity(2, time.Minute),
+		repeat.SetSupervisorBackoff(repeat.FixedBackoff(time.Second)),
+		repeat.SetSupervisorClock(c),
+	).
+		Add("a", scriptedWorker(starts, "a", errPeanut, errPeanut, errPeanut)).
+		Add("b", scriptedWorker(starts, "b"))
+
+	res := goRun(context.Background(), s)
+	for i := 0; i < 2; i++ {
+		c.BlockUntil(2)
+		c.Advance(time.Second)
+	}
+
+	err := <-res
+	require.True(t, errors.Is(err, repeat.ErrRestartIntensity))
+	require.True(t, errors.Is(err, errPeanut))
+	var werr *repeat.WorkerError
+	require.True(t, errors.As(err, &werr))
+	require.Equal(t, "a", werr.Name)
+	require.Len(t, starts, 4)
+}
+
+func TestSupervisor_IntensityPeriod(t *testing.T) {
+	c := repeattest.NewFakeClock(epoch)
+	starts := make(chan string, 10)
+
+	s := repeat.NewSupervisor(
+		repeat.SetSupervisorIntensity(1, time.Second),
+		repeat.SetSupervisorBackoff(repeat.FixedBackoff(time.Second)),
+		repeat.SetSupervisorClock(c),
+	).
+		Add("a", scriptedWorker(starts, "a", errPeanut, errPeanut, nil))
+
+	// Restarts are spread wider than the period.
+	res := goRun(context.Background(), s)
+	for i := 0; i < 2; i++ {
+		c.BlockUntil(2)
+		c.Advance(time.Second)
+	}
+
+	require.NoError(t, <-res)
+	require.Len(t, starts, 3)
+}
+
+func TestSupervisor_Panic(t *testing.T) {
+	s := repeat.NewSupervisor(repeat.SetSupervisorIntensity(0, time.Minute)).
+		Add("a", func(context.Context) error {
+			panic("peanut")
+		})
+
+	err := s.Run(context.Background())
+	var perr *repeat.PanicError
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, "peanut", perr.Value)
+	require.NotEmpty(t, perr.Stack)
+	require.EqualError(t, err, `repeat: restart intensity is exceeded: worker "a": repeat: panic: peanut`)
+}
+
+func TestSupervisor_Stop(t *testing.T) {
+	starts := make(chan string, 10)
+	s := repeat.NewSupervisor().
+		Add("a", scriptedWorker(starts, "a")).
+		Add("b", func(context.Context) error {
+			<-starts
+			return repeat.HintStop(errPeanut)
+		})
+
+	err := s.Run(context.Background())
+	require.Equal(t, &repeat.WorkerError{Name: "b", Err: errPeanut}, err)
+}
+
+func TestSupervisor_Finished(t *testing.T) {
+	s := repeat.NewSupervisor().
+		Add("a", func(context.Context) error { return nil }).
+		Add("b", func(context.Context) error { return repeat.HintStop(nil) })
+
+	require.NoError(t, s.Run(context.Background()))
+	require.NoError(t, repeat.NewSupervisor().Run(context.Background()))
+}
+
+func TestSupervisorStrategy_String(t *testing.T) {
+	require.Equal(t, "one-for-one", repeat.OneForOne.String())
+	require.Equal(t, "one-for-all", repeat.OneForAll.String())
+	require.Equal(t, "unknown", repeat.SupervisorStrategy(-1).String())
+}
@@ -0,0 +1,47 @@
+package repeat
+
+// ValueOperation is the type of function for repetition that produces
+// a value.
+type ValueOperation[T any] func(error) (T, error)
+
+// OnceValue composes the operations with op placed first and executes
+// the result once. It returns the value produced by op alongside the
+// error.
+//
+// It is guaranteed that op will be called at least once.
+func OnceValue[T any](op ValueOperation[T], ops ...Operation) (T, error) {
+	return OnceValueWith(def, op, ops...)
+}
+
+// RepeatValue repeat operations with op placed first until one of them
+// stops the repetition. It returns the value produced by the last call
+// of op alongside the error.
+//
+// It is guaranteed that op will be called at least once.
+func RepeatValue[T any](op ValueOperation[T], ops ...Operation) (T, error) {
+	return RepeatValueWith(def, op, ops...)
+}
+
+// OnceValueWith is the same as OnceValue but uses the given Repeater.
+func OnceValueWith[T any](r Repeater, op ValueOperation[T], ops ...Operation) (T, error) {
+	var v T
+	err := r.Once(append([]Operation{fnValue(op, &v)}, ops...)...)
+
+	return v, err
+}
+
+// RepeatValueWith is the same as RepeatValue but uses the given Repeater.
+func RepeatValueWith[T any](r Repeater, op ValueOperation[T], ops ...Operation) (T, error) {
+	var v T
+	err := r.Repeat(append([]Operation{fnValue(op, &v)}, ops...)...)
+
+	return v, err
+}
+
+// fnValue makes an Operation that stores each value produced by op.
+func fnValue[T any](op ValueOperation[T], v *T) Operation {
+	return func(e error) (err error) {
+		*v, err = op(e)
+		return err
+	}
+}
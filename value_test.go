@@ -0,0 +1,84 @@
+package repeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnceValue(t *testing.T) {
+	v, err := OnceValue(func(e error) (string, error) {
+		require.NoError(t, e)
+		return "kiwi", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "kiwi", v)
+}
+
+func TestOnceValue_Err(t *testing.T) {
+	v, err := OnceValue(func(e error) (int, error) {
+		return 0, HintTemporary(errGolden)
+	})
+	require.Equal(t, errGolden, err)
+	require.Equal(t, 0, v)
+}
+
+func TestRepeatValue(t *testing.T) {
+	cn := 0
+	v, err := RepeatValue(
+		func(e error) (int, error) {
+			cn++
+			if cn < 3 {
+				return -1, HintTemporary(errors.New("not ready"))
+			}
+
+			return 42, nil
+		},
+		StopOnSuccess(),
+		LimitMaxTries(5),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 42, v)
+	require.Equal(t, 3, cn)
+}
+
+func TestRepeatValue_LastValueOnFailure(t *testing.T) {
+	cn := 0
+	v, err := RepeatValue(
+		func(e error) (int, error) {
+			cn++
+			return cn, HintTemporary(errGolden)
+		},
+		StopOnSuccess(),
+		LimitMaxTries(3),
+	)
+	require.Equal(t, errGolden, err)
+	require.Equal(t, cn, v)
+	require.Equal(t, 4, cn)
+}
+
+func TestRepeatValueWith(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	v, err := RepeatValueWith(WithContext(ctx), func(e error) (string, error) {
+		require.Fail(t, "should be never called")
+		return "kiwi", nil
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, "", v)
+}
+
+func TestOnceValueWith(t *testing.T) {
+	cn := 0
+	v, err := OnceValueWith(
+		With(FnS(func() { cn++ }), FnS(func() { cn++ })),
+		func(e error) (*int, error) {
+			return &cn, nil
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 2, *v)
+}
@@ -2,22 +2,27 @@ package repeat
 
 import (
 	"context"
+	"time"
 )
 
 // OpWrapper is the type of function for repetition.
 type OpWrapper func(Operation) Operation
 
+// ContextOpWrapper is the type of function that wraps context-aware
+// operations.
+type ContextOpWrapper func(ContextOperation) ContextOperation
+
 // WrStopOnContextError stops an operation in case of context error.
 func WrStopOnContextError(ctx context.Context) OpWrapper {
 	return func(op Operation) Operation {
 		return func(e error) error {
 			if ctx.Err() != nil {
-				switch e.(type) {
-				case nil:
+				switch {
+				case e == nil:
 					return HintStop(ctx.Err())
-				case *StopError:
+				case IsStop(e):
 					return e
-				case *TemporaryError:
+				case IsTemporary(e):
 					return HintStop(e)
 				default:
 					return e
@@ -50,6 +55,29 @@ func WrWith(c, d Operation) OpWrapper {
 	}
 }
 
+// WrAttemptTimeout returns wrapper that calls op with a context that is
+// canceled after d. An error of the attempt that is timed out is hinted
+// as TemporaryError to let the repetition continue, unless op returns
+// StopError or the parent context is done.
+//
+// Note! op should respect the passed context, the wrapper does not
+// interrupt it.
+func WrAttemptTimeout(d time.Duration) ContextOpWrapper {
+	return func(op ContextOperation) ContextOperation {
+		return func(ctx context.Context, e error) error {
+			attemptCtx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			err := op(attemptCtx, e)
+			if err == nil || IsStop(err) || ctx.Err() != nil || attemptCtx.Err() == nil {
+				return err
+			}
+
+			return HintTemporary(err)
+		}
+	}
+}
+
 // Forward returns the passed operation.
 func Forward(op Operation) Operation {
 	return op
@@ -81,3 +81,65 @@ func TestForward(t *testing.T) {
 	require.Nil(t, Forward(nil))
 	require.Equal(t, reflect.ValueOf(op).Pointer(), reflect.ValueOf(op).Pointer())
 }
+
+// sleepOp waits for d or the context cancellation.
+func sleepOp(d time.Duration) ContextOperation {
+	return func(ctx context.Context, e error) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return e
+		}
+	}
+}
+
+func TestWrAttemptTimeout_TimedOut(t *testing.T) {
+	err := WrAttemptTimeout(time.Millisecond)(sleepOp(time.Minute))(context.Background(), nil)
+	require.True(t, IsTemporary(err))
+	require.Equal(t, context.DeadlineExceeded, Cause(err))
+}
+
+func TestWrAttemptTimeout_InTime(t *testing.T) {
+	op := WrAttemptTimeout(time.Minute)(sleepOp(time.Millisecond))
+	require.NoError(t, op(context.Background(), nil))
+	require.Equal(t, errGolden, op(context.Background(), errGolden))
+}
+
+func TestWrAttemptTimeout_Stop(t *testing.T) {
+	op := WrAttemptTimeout(time.Millisecond)(func(ctx context.Context, e error) error {
+		<-ctx.Done()
+		return HintStop(ctx.Err())
+	})
+	require.EqualError(t, op(context.Background(), nil), "repeat.stop: context deadline exceeded")
+}
+
+func TestWrAttemptTimeout_ParentDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, WrAttemptTimeout(time.Minute)(sleepOp(time.Minute))(ctx, nil))
+}
+
+func TestWrAttemptTimeout_Repeat(t *testing.T) {
+	cn := 0
+	require.NoError(t, Repeat(
+		FnWithContext(context.Background(), WrAttemptTimeout(10*time.Millisecond)(func(ctx context.Context, e error) error {
+			cn++
+			if cn < 3 {
+				return sleepOp(time.Minute)(ctx, e)
+			}
+
+			return nil
+		})),
+		StopOnSuccess(),
+	))
+	require.Equal(t, 3, cn)
+}
+
+func TestFnWithContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), errGolden, "kiwi")
+	require.Equal(t, errGolden, FnWithContext(ctx, func(c context.Context, e error) error {
+		require.Equal(t, ctx, c)
+		return e
+	})(errGolden))
+}